@@ -1,6 +1,6 @@
 module github.com/lukasdietrich/groundwork
 
-go 1.19
+go 1.21
 
 require (
 	github.com/go-sql-driver/mysql v1.6.0
@@ -47,15 +47,27 @@ func newChangelogDao(ctx context.Context, tablename string) (*changelogDao, erro
 }
 
 func (c *changelogDao) setupTable(ctx context.Context) error {
+	capabilities := noorm.CapabilitiesOf(c.dialect)
+
+	create := "create table if not exists"
+	if !capabilities.CreateIfNotExists {
+		if c.tableExists(ctx) {
+			return nil
+		}
+
+		create = "create table"
+	}
+
 	schema := fmt.Sprintf(`
-		create table if not exists %[1]s (
-			%[2]s varchar ( %[5]d ) not null ,
+		%[1]s %[2]s (
 			%[3]s varchar ( %[6]d ) not null ,
 			%[4]s varchar ( %[7]d ) not null ,
+			%[5]s varchar ( %[8]d ) not null ,
 
-			primary key ( %[2]s )
-		) ;
+			primary key ( %[3]s )
+		)%[9]s
 	`,
+		create,
 		c.dialect.QuoteIdentifier(c.tablename),
 		c.dialect.QuoteIdentifier(columnName),
 		c.dialect.QuoteIdentifier(columnHash),
@@ -64,20 +76,49 @@ func (c *changelogDao) setupTable(ctx context.Context) error {
 		nameSize,
 		hashSize,
 		timeSize,
+
+		c.terminator(),
 	)
 
 	_, err := noorm.Exec(ctx, noorm.SQL{Query: schema})
 	return err
 }
 
+// tableExists reports whether the changelog table can be queried. It is used for databases, which
+// do not support `create table if not exists`.
+func (c *changelogDao) tableExists(ctx context.Context) bool {
+	query := fmt.Sprintf(`
+		select %[2]s
+		from %[1]s
+		where 1 = 0%[3]s
+	`,
+		c.dialect.QuoteIdentifier(c.tablename),
+		c.dialect.QuoteIdentifier(columnName),
+		c.terminator(),
+	)
+
+	_, err := noorm.Exec(ctx, noorm.SQL{Query: query})
+	return err == nil
+}
+
+// terminator returns the suffix of statements (see noorm.Capabilities).
+func (c *changelogDao) terminator() string {
+	if noorm.CapabilitiesOf(c.dialect).Semicolons {
+		return " ;"
+	}
+
+	return ""
+}
+
 func (c *changelogDao) lookup(ctx context.Context, name string) (*changelogEntry, error) {
 	query := fmt.Sprintf(`
 		select *
 		from %[1]s
-		where %[2]s = @0 ;
+		where %[2]s = @0%[3]s
 	`,
 		c.dialect.QuoteIdentifier(c.tablename),
 		c.dialect.QuoteIdentifier(columnName),
+		c.terminator(),
 	)
 
 	return noorm.QueryFirst[changelogEntry](ctx, noorm.SQL{
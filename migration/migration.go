@@ -48,7 +48,8 @@ type Changeset interface {
 // Up applies all changesets in order, if they have not already been applied.
 // All changesets are applied within a transaction.
 // When an error occurs, the process will stop, but previously applied changesets won't be rolled
-// back.
+// back. Databases without transactional DDL (eg. MySQL, see noorm.Capabilities) do not roll back
+// the schema changes of the failed changeset either.
 // When a changeset was already applied, but does not match in content, an errors is returned.
 // Up expects a groundwork/noorm.Querier to be present in the context (see WithDatabase).
 func Up(ctx context.Context, changesets []Changeset, opts *Options) ([]Changeset, error) {
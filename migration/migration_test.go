@@ -2,6 +2,7 @@ package migration
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -51,3 +52,40 @@ func TestSqliteMigration(t *testing.T) {
 	applied, err = Up(ctx, changesets, nil)
 	require.ErrorIs(t, err, ErrHashMismatch)
 }
+
+type testDialect struct {
+	noorm.Dialect
+}
+
+func (d testDialect) Capabilities() noorm.Capabilities {
+	capabilities := noorm.CapabilitiesOf(d.Dialect)
+	capabilities.CreateIfNotExists = false
+	capabilities.Semicolons = false
+
+	return capabilities
+}
+
+func TestMigrationCapabilities(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	defer sqlDB.Close()
+
+	// sqlite requires the in-memory database to stay on a single connection
+	sqlDB.SetMaxOpenConns(1)
+
+	db := noorm.New(sqlDB, testDialect{noorm.SQLite()})
+	ctx := noorm.WithDatabase(context.Background(), db)
+
+	changesets := []Changeset{
+		LiteralChangeset("1", `create table "fruits" ( "name" varchar not null )`),
+	}
+
+	applied, err := Up(ctx, changesets, nil)
+	require.NoError(t, err)
+	assert.Equal(t, changesets, applied)
+
+	applied, err = Up(ctx, changesets, nil)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
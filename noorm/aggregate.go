@@ -0,0 +1,214 @@
+package noorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// QueryAggregate executes a query and folds consecutive rows sharing the same key into a single T.
+// The rows of a one-to-many join are mapped like in Query, with the columns of the children being
+// mapped into prefixed slices of structs (eg. `db:"posts,prefix=post_"`). The children of all
+// rows sharing a key are appended to the first T with that key. Children, whose columns are all
+// null (eg. of a `left join` without match), are skipped. If the type of the children has a
+// primary key, children already appended are skipped as well, which allows multiple collections
+// per T.
+// The key columns default to the primary key of T. Rows must be ordered by the key for the
+// aggregation to work.
+// QueryAggregate expects a Querier to be present in the context (see WithDatabase).
+func QueryAggregate[T any](ctx context.Context, query QuerySource, key ...string) ([]T, error) {
+	_, db, err := querierFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregator, err := newAggregator(typeOfGeneric[T](), key, db.getNaming())
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := Iterate[T](ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+
+	var valueSlice []T
+
+	for iter.Next() {
+		value, err := iter.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(valueSlice); n > 0 {
+			last := reflect.ValueOf(&valueSlice[n-1]).Elem()
+
+			if aggregator.merge(last, reflect.ValueOf(value)) {
+				continue
+			}
+		}
+
+		aggregator.start(reflect.ValueOf(value))
+		valueSlice = append(valueSlice, value)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return valueSlice, nil
+}
+
+// aggregator merges rows sharing the same key.
+type aggregator struct {
+	key         [][]int // paths of field indices of the key
+	collections []*aggregateCollection
+}
+
+// aggregateCollection is a prefixed slice of structs, whose elements are merged.
+type aggregateCollection struct {
+	index []int   // path of field indices to the slice
+	key   [][]int // paths of field indices of the primary key within the elements
+	// seen are the keys of the elements of the current value.
+	seen map[string]bool
+}
+
+func newAggregator(t reflect.Type, key []string, naming *naming) (*aggregator, error) {
+	lookup, err := naming.lookup(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) == 0 {
+		key = lookup.columns(func(field fieldInfo) bool {
+			return field.pk
+		})
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: aggregating %q requires key columns or a primary key",
+			ErrInvalidArg, t)
+	}
+
+	var a aggregator
+
+	for _, column := range key {
+		field, ok := lookup[column]
+		if !ok || field.elem != nil {
+			return nil, fmt.Errorf("%w: key column %q is not a field of %q outside of collections",
+				ErrInvalidArg, column, t)
+		}
+
+		a.key = append(a.key, field.index)
+	}
+
+	collections := make(map[string]bool)
+
+	for _, field := range lookup {
+		name := fmt.Sprint(field.index)
+		if field.elem == nil || collections[name] {
+			continue
+		}
+
+		collections[name] = true
+
+		collectionType, _ := fieldTypeByIndex(t, field.index)
+		elemLookup, err := naming.lookup(collectionType.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		collection := aggregateCollection{index: field.index}
+		for _, column := range elemLookup.columns(func(field fieldInfo) bool { return field.pk }) {
+			collection.key = append(collection.key, elemLookup[column].index)
+		}
+
+		a.collections = append(a.collections, &collection)
+	}
+
+	return &a, nil
+}
+
+// start registers the children of a value, which starts a new aggregate.
+func (a *aggregator) start(value reflect.Value) {
+	for _, collection := range a.collections {
+		collection.seen = make(map[string]bool)
+
+		elems := fieldByIndexOrInvalid(value, collection.index)
+		for i := 0; elems.IsValid() && i < elems.Len(); i++ {
+			collection.add(elems.Index(i))
+		}
+	}
+}
+
+// merge appends the children of value to the aggregate, if both share the same key.
+func (a *aggregator) merge(aggregate, value reflect.Value) bool {
+	for _, index := range a.key {
+		if !reflect.DeepEqual(
+			valueOrNil(fieldByIndexOrInvalid(aggregate, index)),
+			valueOrNil(fieldByIndexOrInvalid(value, index)),
+		) {
+			return false
+		}
+	}
+
+	for _, collection := range a.collections {
+		elems := fieldByIndexOrInvalid(value, collection.index)
+		if !elems.IsValid() || elems.Len() == 0 {
+			continue
+		}
+
+		initializeFieldPath(aggregate, collection.index)
+		target := aggregate.FieldByIndex(collection.index)
+
+		for i := 0; i < elems.Len(); i++ {
+			if elem := elems.Index(i); collection.add(elem) {
+				target.Set(reflect.Append(target, elem))
+			}
+		}
+	}
+
+	return true
+}
+
+// add reports whether elem has not been seen before and remembers it.
+// Elements without a primary key are always added.
+func (c *aggregateCollection) add(elem reflect.Value) bool {
+	if len(c.key) == 0 {
+		return true
+	}
+
+	key := make([]any, len(c.key))
+	for i, index := range c.key {
+		key[i] = valueOrNil(fieldByIndexOrInvalid(elem, index))
+	}
+
+	name := fmt.Sprintf("%#v", key)
+	if c.seen[name] {
+		return false
+	}
+
+	c.seen[name] = true
+	return true
+}
+
+// fieldByIndexOrInvalid returns the nested field of v. If the path traverses a nil pointer, the
+// returned value is invalid.
+func fieldByIndexOrInvalid(v reflect.Value, index []int) reflect.Value {
+	field, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}
+	}
+
+	return field
+}
+
+func valueOrNil(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,72 @@
+package noorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAggregate(t *testing.T) {
+	type Tag struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	type Post struct {
+		ID   int    `db:"id,pk"`
+		Text string `db:"text"`
+	}
+
+	type User struct {
+		ID    int    `db:"id,pk"`
+		Name  string `db:"name"`
+		Posts []Post `db:"posts,prefix=post_"`
+		Tags  []Tag  `db:"tags,prefix=tag_"`
+	}
+
+	db, err := Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	ctx := WithDatabase(context.Background(), db)
+
+	// posts and tags of user 1 yield their cartesian product
+	query := SQL{
+		Query: `
+			with "rows" ( "id", "name", "post_id", "post_text", "tag_id", "tag_name" ) as (
+				values
+					( 1, 'Foo', 1,    'Hello', 1,    'a'  ),
+					( 1, 'Foo', 1,    'Hello', 2,    'b'  ),
+					( 1, 'Foo', 2,    'World', 1,    'a'  ),
+					( 1, 'Foo', 2,    'World', 2,    'b'  ),
+					( 2, 'Bar', null, null,    null, null )
+			)
+			select * from "rows" ;
+		`,
+	}
+
+	users, err := QueryAggregate[User](ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, []User{
+		{
+			ID:    1,
+			Name:  "Foo",
+			Posts: []Post{{ID: 1, Text: "Hello"}, {ID: 2, Text: "World"}},
+			Tags:  []Tag{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}},
+		},
+		{ID: 2, Name: "Bar"},
+	}, users)
+
+	users, err = QueryAggregate[User](ctx, query, "name")
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	_, err = QueryAggregate[User](ctx, query, "post_id")
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	_, err = QueryAggregate[testStructUser](ctx, query)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
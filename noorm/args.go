@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"unicode"
-	"unicode/utf8"
 )
 
 var (
@@ -32,6 +32,23 @@ func checkValidArgs(args ArgumentSource) error {
 	return nil
 }
 
+// missingArgError is returned by an ArgumentSource, which does not provide a name.
+type missingArgError struct {
+	name string
+	// source describes the ArgumentSource.
+	source string
+	// reason explains why the name is missing.
+	reason string
+}
+
+func (e *missingArgError) Error() string {
+	return fmt.Sprintf("%s: wanted %q, but %s", ErrInvalidArg, e.name, e.reason)
+}
+
+func (e *missingArgError) Unwrap() error {
+	return ErrInvalidArg
+}
+
 type noneArgs struct{}
 
 // None is used when you do not need to provide any arguments for a query.
@@ -40,39 +57,168 @@ func None() ArgumentSource {
 }
 
 func (noneArgs) arg(name string) (any, error) {
-	return nil, fmt.Errorf("%w: wanted %q, but provided None", ErrInvalidArg, name)
+	return nil, &missingArgError{name: name, source: "None", reason: "provided None"}
 }
 
 type namedArgs struct {
+	// lookupMap is nil until the argument source is adapted to the naming of the database.
 	lookupMap fieldLookupMap
 	value     reflect.Value
 }
 
 // Named uses the fields of a struct as named arguments for a query.
-// Field names can be overwritten with struct tags.
+// Field names can be overwritten with struct tags. Fields tagged `db:"-"` are ignored.
+// Fields of nested structs tagged with the `prefix` option are available by their prefixed name
+// and by their dotted name (eg. `@author_id` and `@author.id` for `db:"author,prefix=author_"`).
 func Named(args Struct) ArgumentSource {
 	v := indirectInterface(reflect.Indirect(reflect.ValueOf(&args)))
+
 	t := v.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return invalidArg{fmt.Errorf("%w: cannot traverse %q, expected a struct",
+			ErrInvalidTargetType, t)}
+	}
 
-	lookupMap, err := buildFieldLookupMapOfType(t)
+	// the fields are looked up by the naming of the database (see withNaming)
+	return &namedArgs{value: v}
+}
+
+// namingArgs is implemented by argument sources, which depend on the naming of the database.
+type namingArgs interface {
+	withNaming(naming *naming) ArgumentSource
+}
+
+// withNaming returns the argument source adapted to the naming of the database.
+func withNaming(args ArgumentSource, naming *naming) ArgumentSource {
+	if args, ok := args.(namingArgs); ok {
+		return args.withNaming(naming)
+	}
+
+	return args
+}
+
+func (a *namedArgs) withNaming(naming *naming) ArgumentSource {
+	lookupMap, err := naming.lookup(a.value.Type())
 	if err != nil {
 		return invalidArg{err}
 	}
 
 	return &namedArgs{
 		lookupMap: lookupMap,
-		value:     v,
+		value:     a.value,
+	}
+}
+
+// namedModel is like Named, but returns the concrete type required to generate queries from the
+// fields of a struct.
+func namedModel(model Struct, naming *naming) (*namedArgs, error) {
+	switch args := withNaming(Named(model), naming).(type) {
+	case invalidArg:
+		return nil, args.error
+
+	case *namedArgs:
+		return args, nil
+	}
+
+	return nil, ErrInvalidArg
+}
+
+// lookup returns the lookup map adapted to the naming of the database or of the default naming, if
+// the argument source has not been adapted.
+func (a *namedArgs) lookup() (fieldLookupMap, error) {
+	if a.lookupMap != nil {
+		return a.lookupMap, nil
 	}
+
+	return defaultNaming.lookup(a.value.Type())
 }
 
 func (a *namedArgs) arg(name string) (any, error) {
-	index, ok := a.lookupMap[name]
+	lookupMap, err := a.lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	field, ok := lookupMap[name]
 	if !ok {
-		return nil, fmt.Errorf("%w: wanted %q, but is not in %q",
-			ErrInvalidArg, name, a.value.Type())
+		source := fmt.Sprintf("%q", a.value.Type())
+		return nil, &missingArgError{name: name, source: source, reason: "is not in " + source}
+	}
+
+	if field.elem != nil {
+		source := fmt.Sprintf("%q", a.value.Type())
+		return nil, &missingArgError{name: name, source: source, reason: "is within a collection"}
+	}
+
+	// fields within nil pointer structs are passed as null
+	return valueOrNil(a.field(field)), nil
+}
+
+// field returns the value of a field. If the field is within a nil pointer struct, the returned
+// value is invalid.
+func (a *namedArgs) field(field fieldInfo) reflect.Value {
+	return fieldByIndexOrInvalid(reflect.Indirect(a.value), field.index)
+}
+
+// writableColumns returns the sorted columns, which are written by generated inserts and updates.
+func (a *namedArgs) writableColumns() []string {
+	return a.lookupMap.columns(func(field fieldInfo) bool {
+		return field.writable() && !(field.omitempty && isZeroOrInvalid(a.field(field)))
+	})
+}
+
+func isZeroOrInvalid(value reflect.Value) bool {
+	return !value.IsValid() || value.IsZero()
+}
+
+// primaryKey returns the sorted columns tagged with the `pk` option.
+func (a *namedArgs) primaryKey() []string {
+	return a.lookupMap.columns(func(field fieldInfo) bool {
+		return field.pk
+	})
+}
+
+// checkColumns returns an error, if any of the columns is not a field of the struct.
+func (a *namedArgs) checkColumns(columns []string) error {
+	for _, column := range columns {
+		field, ok := a.lookupMap[column]
+		if !ok {
+			return fmt.Errorf("%w: column %q is not in %q", ErrInvalidArg, column, a.value.Type())
+		}
+
+		if field.aliasOf != "" {
+			return fmt.Errorf("%w: column %q of %q is an alias, use %q instead",
+				ErrInvalidArg, column, a.value.Type(), field.aliasOf)
+		}
+
+		if field.elem != nil {
+			return fmt.Errorf("%w: column %q of %q is within a collection",
+				ErrInvalidArg, column, a.value.Type())
+		}
+	}
+
+	return nil
+}
+
+// checkWritableColumns returns an error, if any of the columns is not a field of the struct or may
+// not be written.
+func (a *namedArgs) checkWritableColumns(columns []string) error {
+	if err := a.checkColumns(columns); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if !a.lookupMap[column].writable() {
+			return fmt.Errorf("%w: column %q of %q is not writable",
+				ErrInvalidArg, column, a.value.Type())
+		}
 	}
 
-	return reflect.Indirect(a.value).FieldByIndex(index).Interface(), nil
+	return nil
 }
 
 type positionalArgs []any
@@ -84,105 +230,134 @@ func Positional(args ...any) ArgumentSource {
 }
 
 func (a positionalArgs) arg(name string) (any, error) {
+	source := fmt.Sprintf("Positional[0,%d)", len(a))
+
 	i, err := strconv.Atoi(name)
 	if err != nil {
-		return nil, fmt.Errorf("%w: wanted %q, but is not a number", ErrInvalidArg, name)
+		return nil, &missingArgError{name: name, source: source, reason: "is not a number"}
 	}
 
 	if i < 0 || i >= len(a) {
-		return nil, fmt.Errorf("%w: wanted %q, but is out of range [0,%d)",
-			ErrInvalidArg, name, len(a))
+		reason := fmt.Sprintf("is out of range [0,%d)", len(a))
+		return nil, &missingArgError{name: name, source: source, reason: reason}
 	}
 
 	return a[i], nil
 }
 
-var _ driver.Valuer = nullableValue{}
+type mapArgs map[string]any
+
+// Map uses the keys of a map as named arguments for a query.
+func Map(args map[string]any) ArgumentSource {
+	for name := range args {
+		if !isValidFieldName(name) {
+			return invalidArg{fmt.Errorf("%w: invalid map key %q", ErrInvalidArg, name)}
+		}
+	}
 
-type nullableValue struct {
-	value reflect.Value
+	return mapArgs(args)
 }
 
-func (n nullableValue) Value() (driver.Value, error) {
-	if n.value.Kind() == reflect.Pointer && n.value.IsNil() {
-		return nil, nil
+func (a mapArgs) arg(name string) (any, error) {
+	value, ok := a[name]
+	if !ok {
+		return nil, &missingArgError{name: name, source: "Map", reason: "is not in Map"}
 	}
 
-	return n.value.Interface(), nil
+	return value, nil
 }
 
-// rebindQuery parses the query and replaces named paremeters with the database specific
-// placeholder. Named parameters have the form `@name` where `name` is the actual name.
-// A literal `@` can be written by doubling it `@@`. Only letters, numbers, dashes and underscores
-// are permitted as names.
-func rebindQuery(dialect Dialect, query string, args ArgumentSource) (string, []any, error) {
-	const at = '@'
+type mergedArgs []ArgumentSource
 
-	var (
-		queryBuffer    bytes.Buffer
-		nameBuffer     bytes.Buffer
-		parameterSlice []any
-		byteOffset     int
-		inParam        bool
-	)
+// Merge combines multiple argument sources into one. Sources are searched in order and the first
+// source providing a name wins, so that earlier sources take precedence over later ones.
+// A typical use is to add a few extra arguments to a struct:
+//
+//	Merge(Map(map[string]any{"limit": 10}), Named(filter))
+func Merge(sources ...ArgumentSource) ArgumentSource {
+	merged := make(mergedArgs, 0, len(sources))
 
-	for _, r := range query {
-		byteOffset += utf8.RuneLen(r)
-		endOfQuery := byteOffset >= len(query)
-		wasInParam := inParam
+	for _, source := range sources {
+		if source == nil {
+			continue
+		}
+
+		if err := checkValidArgs(source); err != nil {
+			return invalidArg{err}
+		}
+
+		merged = append(merged, source)
+	}
+
+	return merged
+}
 
-		if inParam {
-			if !isParameterNameRune(r) {
-				if nameBuffer.Len() == 1 {
-					wasInParam = false
+func (a mergedArgs) withNaming(naming *naming) ArgumentSource {
+	merged := make(mergedArgs, len(a))
+	for i, source := range a {
+		merged[i] = withNaming(source, naming)
+	}
 
-					if r != at {
-						nameBuffer.WriteTo(&queryBuffer)
-					}
+	return merged
+}
 
-					nameBuffer.Reset()
-				}
+func (a mergedArgs) arg(name string) (any, error) {
+	searched := make([]string, 0, len(a))
 
-				inParam = false
-			}
-		} else {
-			if r == at {
-				inParam = true
-			}
+	for _, source := range a {
+		value, err := source.arg(name)
+		if err == nil {
+			return value, nil
 		}
 
-		if inParam {
-			nameBuffer.WriteRune(r)
+		var missing *missingArgError
+		if !errors.As(err, &missing) {
+			return nil, err
 		}
 
-		if wasInParam && (!inParam || endOfQuery) {
-			name := nameBuffer.String()
-			nameBuffer.Reset()
+		searched = append(searched, missing.source)
+	}
 
-			arg, err := args.arg(name[1:])
-			if err != nil {
-				return query, nil, err
-			}
+	source := "Merge[" + strings.Join(searched, ", ") + "]"
+	reason := "is not in any of [" + strings.Join(searched, ", ") + "]"
 
-			argValues := splitArg(arg)
-			repeatPlaceholder(&queryBuffer, dialect, len(parameterSlice), len(argValues))
+	return nil, &missingArgError{name: name, source: source, reason: reason}
+}
 
-			parameterSlice = append(parameterSlice, argValues...)
-		}
+// List is a slice, which is always expanded into a comma separated list of parameters.
+// An empty list is rendered as `null`, so that `x in (@list)` remains valid sql, but is never true.
+// Because `x not in (null)` is never true either, an empty list making up the list of a `not in` is
+// rejected with ErrInvalidArg.
+type List[T any] []T
 
-		if !inParam {
-			queryBuffer.WriteRune(r)
-		}
+func (l List[T]) listValues() []any {
+	values := make([]any, len(l))
+	for i, value := range l {
+		values[i] = value
 	}
 
-	return queryBuffer.String(), parameterSlice, nil
+	return values
 }
 
-func isParameterNameRune(r rune) bool {
-	return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '-' || r == '_'
+// Array is a slice, which is always bound as a single parameter, eg. for drivers with native
+// support of arrays.
+type Array[T any] []T
+
+func (a Array[T]) arrayValue() any {
+	return []T(a)
 }
 
+type (
+	listArg  interface{ listValues() []any }
+	arrayArg interface{ arrayValue() any }
+)
+
 func repeatPlaceholder(buffer *bytes.Buffer, dialect Dialect, position, n int) {
+	if n == 0 {
+		buffer.WriteString("null")
+		return
+	}
+
 	for i := 0; i < n; i++ {
 		if i > 0 {
 			buffer.WriteString(", ")
@@ -192,10 +367,55 @@ func repeatPlaceholder(buffer *bytes.Buffer, dialect Dialect, position, n int) {
 	}
 }
 
+// isNotInList reports whether the sql ends with the opening parenthesis of a `not in` list.
+func isNotInList(sql string) bool {
+	sql, ok := cutTrailingToken(sql, "(")
+	if ok {
+		sql, ok = cutTrailingToken(sql, "in")
+	}
+
+	if ok {
+		_, ok = cutTrailingToken(sql, "not")
+	}
+
+	return ok
+}
+
+// cutTrailingToken removes the token and any whitespace following it from the end of the sql.
+// Keywords are matched case insensitive and must not be preceded by a letter or digit.
+func cutTrailingToken(sql, token string) (string, bool) {
+	sql = strings.TrimRightFunc(sql, unicode.IsSpace)
+
+	if len(sql) < len(token) || !strings.EqualFold(sql[len(sql)-len(token):], token) {
+		return sql, false
+	}
+
+	sql = sql[:len(sql)-len(token)]
+
+	if token != "(" && sql != "" && isParameterNameRune(rune(sql[len(sql)-1])) {
+		return sql, false
+	}
+
+	return sql, true
+}
+
+// splitArg returns the parameters of an argument. Slices are expanded into one parameter per element
+// unless they are byte slices, implement driver.Valuer or are wrapped in an Array.
 func splitArg(arg any) []any {
+	switch arg := arg.(type) {
+	case listArg:
+		return arg.listValues()
+
+	case arrayArg:
+		return []any{arg.arrayValue()}
+
+	case driver.Valuer:
+		return []any{arg}
+	}
+
 	v := reflect.ValueOf(arg)
 
-	if v.Kind() != reflect.Slice {
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
 		return []any{arg}
 	}
 
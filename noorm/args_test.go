@@ -1,6 +1,8 @@
 package noorm
 
 import (
+	"database/sql/driver"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -63,6 +65,121 @@ func TestNamed(t *testing.T) {
 	}
 }
 
+func TestNamedNaming(t *testing.T) {
+	// both fields are named "Foo" by the default naming, but not by the naming of the database
+	type TestStruct struct {
+		Foo string
+		Bar string `db:"Foo"`
+	}
+
+	args := Named(TestStruct{Foo: "foo", Bar: "bar"})
+	require.NoError(t, checkValidArgs(args))
+
+	query, params, err := rebindQuery(defaultDialect{}, newNaming(SnakeCaseNames, false),
+		`select @foo, @Foo ;`, args)
+
+	require.NoError(t, err)
+	assert.Equal(t, `select ?, ? ;`, query)
+	assert.Equal(t, []any{"foo", "bar"}, params)
+
+	_, err = args.arg("Foo")
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+
+	_, _, err = SQL{Query: `select 1 ;`, Args: Named(1)}.rebind(defaultDialect{}, defaultNaming)
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+}
+
+func TestNamedOptions(t *testing.T) {
+	type TestStruct struct {
+		Field1 string `db:"field_1,omitempty"`
+		Field2 string `db:"field_2,omitempty"`
+		Field3 string `db:"-"`
+	}
+
+	args := Named(TestStruct{
+		Field1: "test",
+		Field3: "ignored",
+	})
+
+	arg, err := args.arg("field_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", arg)
+
+	// omitempty only affects generated queries
+	arg, err = args.arg("field_2")
+	assert.NoError(t, err)
+	assert.Equal(t, "", arg)
+
+	_, err = args.arg("Field3")
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
+
+func TestMap(t *testing.T) {
+	args := Map(map[string]any{
+		"limit":     10,
+		"tenant_id": "abc",
+	})
+
+	for _, tc := range []struct {
+		name string
+		err  bool
+		arg  any
+	}{
+		{name: "limit", arg: 10},
+		{name: "tenant_id", arg: "abc"},
+		{name: "offset", err: true},
+	} {
+		arg, err := args.arg(tc.name)
+		if tc.err {
+			assert.ErrorIs(t, err, ErrInvalidArg)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.arg, arg)
+		}
+	}
+
+	assert.ErrorIs(t, checkValidArgs(Map(map[string]any{"not valid": 1})), ErrInvalidArg)
+}
+
+func TestMerge(t *testing.T) {
+	type TestStruct struct {
+		Name  string `db:"name"`
+		Limit int    `db:"limit"`
+	}
+
+	args := Merge(
+		Map(map[string]any{"limit": 10}),
+		Named(TestStruct{Name: "test", Limit: 20}),
+		Positional("first"),
+	)
+
+	for _, tc := range []struct {
+		name string
+		arg  any
+	}{
+		{name: "limit", arg: 10},
+		{name: "name", arg: "test"},
+		{name: "0", arg: "first"},
+	} {
+		arg, err := args.arg(tc.name)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.arg, arg)
+	}
+
+	_, err := args.arg("offset")
+	assert.ErrorIs(t, err, ErrInvalidArg)
+	assert.EqualError(t, err, `noorm: invalid arg: wanted "offset", but is not in any of `+
+		`[Map, "noorm.TestStruct", Positional[0,1)]`)
+
+	assert.ErrorIs(t, checkValidArgs(Merge(None(), Named(42))), ErrInvalidTargetType)
+}
+
+type testValuerSlice []string
+
+func (s testValuerSlice) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
 func TestRebind(t *testing.T) {
 	type expected struct {
 		query      string
@@ -74,10 +191,31 @@ func TestRebind(t *testing.T) {
 		args  ArgumentSource
 	}
 
+	type testAuthor struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
 	for _, tc := range []struct {
 		input
 		expected
 	}{
+		{
+			input{
+				query: `select * from t where a = @author.id and b = @author_name and c = @id ;`,
+				args: Named(struct {
+					ID     int        `db:"id"`
+					Author testAuthor `db:"author,prefix=author_"`
+				}{
+					ID:     1,
+					Author: testAuthor{ID: 2, Name: "Foo"},
+				}),
+			},
+			expected{
+				query:      `select * from t where a = ? and b = ? and c = ? ;`,
+				parameters: []any{2, "Foo", 1},
+			},
+		},
 		{
 			input{
 				query: `select * from t where a = @0 and b in (@1) ;`,
@@ -124,6 +262,51 @@ func TestRebind(t *testing.T) {
 				parameters: []any{3, 1},
 			},
 		},
+		{
+			input{
+				query: `select * from t where a = @name limit @limit ;`,
+				args: Merge(
+					Map(map[string]any{"limit": 10}),
+					Named(struct {
+						Name string `db:"name"`
+					}{
+						Name: "a",
+					}),
+				),
+			},
+			expected{
+				query:      `select * from t where a = ? limit ? ;`,
+				parameters: []any{"a", 10},
+			},
+		},
+		{
+			input{
+				query: `select * from t where a in (@0) and b in (@1) and c in (@2) ;`,
+				args:  Positional([]int{}, List[string](nil), List[int]{1, 2}),
+			},
+			expected{
+				query:      `select * from t where a in (null) and b in (null) and c in (?, ?) ;`,
+				parameters: []any{1, 2},
+			},
+		},
+		{
+			input{
+				query: `insert into t ( a, b, c ) values ( @Blob, @Array, @Valuer ) ;`,
+				args: Named(struct {
+					Blob   []byte
+					Array  Array[int]
+					Valuer testValuerSlice
+				}{
+					Blob:   []byte("blob"),
+					Array:  Array[int]{1, 2},
+					Valuer: testValuerSlice{"a", "b"},
+				}),
+			},
+			expected{
+				query:      `insert into t ( a, b, c ) values ( ?, ?, ? ) ;`,
+				parameters: []any{[]byte("blob"), []int{1, 2}, testValuerSlice{"a", "b"}},
+			},
+		},
 		{
 			input{
 				query: `select * from t where a = '@@' and b = '@ hello' ;`,
@@ -134,11 +317,43 @@ func TestRebind(t *testing.T) {
 				parameters: nil,
 			},
 		},
+		{
+			input{
+				query: `select * from t where a = @@b -- @@c
+					and d = '@e' ;`,
+				args: Positional(1, 2, 3, 4),
+			},
+			expected{
+				query: `select * from t where a = @b -- @c
+					and d = '@e' ;`,
+				parameters: nil,
+			},
+		},
 	} {
-		query, args, err := rebindQuery(defaultDialect{}, tc.input.query, tc.input.args)
+		query, args, err := rebindQuery(defaultDialect{}, defaultNaming, tc.input.query, tc.input.args)
 
 		require.NoError(t, err)
 		assert.Equal(t, tc.expected.query, query)
 		assert.Equal(t, tc.expected.parameters, args)
 	}
 }
+
+func TestRebindEmptyListNotIn(t *testing.T) {
+	for _, query := range []string{
+		`select * from t where a not in (@0) ;`,
+		`select * from t where a NOT IN(@0) ;`,
+		`select * from t where a not
+			in ( @0 ) ;`,
+	} {
+		_, _, err := rebindQuery(defaultDialect{}, defaultNaming, query, Positional(List[int]{}))
+		assert.ErrorIs(t, err, ErrInvalidArg, "query=%q", query)
+	}
+
+	for _, query := range []string{
+		`select * from t where a in (@0) ;`,
+		`select * from t where knot in (@0) ;`,
+	} {
+		_, _, err := rebindQuery(defaultDialect{}, defaultNaming, query, Positional(List[int]{}))
+		assert.NoError(t, err, "query=%q", query)
+	}
+}
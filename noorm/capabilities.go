@@ -0,0 +1,147 @@
+package noorm
+
+import "strconv"
+
+// Capabilities describes the features and syntax variants of a database, so that portable code
+// does not need to know the concrete Dialect. The zero value describes a database without any of
+// the optional features.
+type Capabilities struct {
+	// MaxParameters is the maximum number of parameters in a single query.
+	MaxParameters int
+	// MaxInsertRows is the maximum number of rows in a single insert query or 0 if there is no
+	// limit apart from the number of parameters.
+	MaxInsertRows int
+	// Returning reports whether insert queries can return the inserted rows (see InsertReturning).
+	Returning bool
+	// ReturningInto reports whether insert queries can return the values of the inserted row into
+	// out parameters using `returning ... into` (eg. Oracle, see InsertReturning).
+	ReturningInto bool
+	// Savepoints is the syntax of savepoints, which nest transactions (see Begin).
+	Savepoints SavepointSyntax
+	// Upsert is the syntax of upsert queries (see Upsert).
+	Upsert UpsertSyntax
+	// Booleans is the syntax of boolean literals.
+	Booleans BooleanSyntax
+	// Pagination is the syntax to limit the rows of a query (see Paginate).
+	Pagination PaginationSyntax
+	// TransactionalDDL reports whether schema changes (eg. `create table`) are rolled back with
+	// the enclosing transaction.
+	TransactionalDDL bool
+	// CreateIfNotExists reports whether `create table if not exists` is supported.
+	CreateIfNotExists bool
+	// Semicolons reports whether statements may be terminated by a semicolon. Some drivers reject
+	// terminated statements (eg. Oracle).
+	Semicolons bool
+}
+
+// SavepointSyntax is the syntax of savepoints.
+type SavepointSyntax int
+
+const (
+	// SavepointsUnsupported means that transactions cannot be nested.
+	SavepointsUnsupported SavepointSyntax = iota
+	// SavepointsStandard uses `savepoint s`, `release savepoint s` and `rollback to savepoint s`.
+	SavepointsStandard
+	// SavepointsWithoutRelease uses `savepoint s` and `rollback to savepoint s`. Savepoints are
+	// not released, but remain until the end of the transaction (eg. Oracle).
+	SavepointsWithoutRelease
+	// SavepointsTransact uses `save transaction s` and `rollback transaction s` (eg. SQL Server).
+	SavepointsTransact
+)
+
+// savepointStatements returns the statements to create, release and roll back to a savepoint.
+// The release statement is empty, if savepoints are not released.
+func (s SavepointSyntax) savepointStatements(name string) (create, release, rollback string) {
+	switch s {
+	case SavepointsStandard:
+		return "savepoint " + name, "release savepoint " + name, "rollback to savepoint " + name
+
+	case SavepointsWithoutRelease:
+		return "savepoint " + name, "", "rollback to savepoint " + name
+
+	case SavepointsTransact:
+		return "save transaction " + name, "", "rollback transaction " + name
+	}
+
+	return "", "", ""
+}
+
+// UpsertSyntax is the syntax of upsert queries.
+type UpsertSyntax int
+
+const (
+	// UpsertUnsupported means that upsert queries cannot be generated.
+	UpsertUnsupported UpsertSyntax = iota
+	// UpsertOnConflict uses `insert ... on conflict (...) do update set ...`.
+	UpsertOnConflict
+	// UpsertOnDuplicateKey uses `insert ... on duplicate key update ...`.
+	UpsertOnDuplicateKey
+	// UpsertMerge uses `merge into ... using ... when matched then update ...`.
+	UpsertMerge
+)
+
+// BooleanSyntax is the syntax of boolean literals.
+type BooleanSyntax int
+
+const (
+	// BooleanKeywords uses `true` and `false`.
+	BooleanKeywords BooleanSyntax = iota
+	// BooleanIntegers uses `1` and `0`, because booleans are not a type of their own.
+	BooleanIntegers
+)
+
+// Literal returns the boolean literal of b.
+func (s BooleanSyntax) Literal(b bool) string {
+	if s == BooleanIntegers {
+		if b {
+			return "1"
+		}
+
+		return "0"
+	}
+
+	return strconv.FormatBool(b)
+}
+
+// PaginationSyntax is the syntax to limit the rows of a query.
+type PaginationSyntax int
+
+const (
+	// PaginationLimitOffset uses `limit n offset m`.
+	PaginationLimitOffset PaginationSyntax = iota
+	// PaginationOffsetFetch uses `offset m rows fetch next n rows only` of standard sql.
+	PaginationOffsetFetch
+)
+
+// CapabilitiesDialect is an optional extension of Dialect to describe its capabilities.
+type CapabilitiesDialect interface {
+	Dialect
+	// Capabilities returns the capabilities of the database.
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the capabilities of a dialect. Dialects without the CapabilitiesDialect
+// extension are assumed to have the capabilities of DefaultDialect, but only support upserts if
+// they implement UpsertDialect.
+func CapabilitiesOf(dialect Dialect) Capabilities {
+	if capabilitiesDialect, ok := dialect.(CapabilitiesDialect); ok {
+		return capabilitiesDialect.Capabilities()
+	}
+
+	capabilities := defaultDialect{}.Capabilities()
+
+	if _, ok := dialect.(UpsertDialect); !ok {
+		capabilities.Upsert = UpsertUnsupported
+	}
+
+	return capabilities
+}
+
+// terminatorOf returns the suffix of generated queries.
+func terminatorOf(dialect Dialect) string {
+	if CapabilitiesOf(dialect).Semicolons {
+		return " ;"
+	}
+
+	return ""
+}
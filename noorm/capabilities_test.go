@@ -0,0 +1,88 @@
+package noorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilitiesOf(t *testing.T) {
+	for dialect, expected := range map[Dialect]struct {
+		savepoints SavepointSyntax
+		upsert     UpsertSyntax
+		booleans   BooleanSyntax
+		pagination PaginationSyntax
+		ddl        bool
+		semicolons bool
+	}{
+		sqliteDialect{}: {
+			savepoints: SavepointsStandard,
+			upsert:     UpsertOnConflict,
+			ddl:        true,
+			semicolons: true,
+		},
+		postgresDialect{}: {
+			savepoints: SavepointsStandard,
+			upsert:     UpsertOnConflict,
+			ddl:        true,
+			semicolons: true,
+		},
+		mysqlDialect{}: {
+			savepoints: SavepointsStandard,
+			upsert:     UpsertOnDuplicateKey,
+			semicolons: true,
+		},
+		sqlserverDialect{}: {
+			savepoints: SavepointsTransact,
+			upsert:     UpsertMerge,
+			booleans:   BooleanIntegers,
+			pagination: PaginationOffsetFetch,
+			ddl:        true,
+			semicolons: true,
+		},
+		oracleDialect{}: {
+			savepoints: SavepointsWithoutRelease,
+			upsert:     UpsertMerge,
+			booleans:   BooleanIntegers,
+			pagination: PaginationOffsetFetch,
+		},
+	} {
+		capabilities := CapabilitiesOf(dialect)
+		assert.Equal(t, expected.savepoints, capabilities.Savepoints, "dialect=%T", dialect)
+		assert.Equal(t, expected.upsert, capabilities.Upsert, "dialect=%T", dialect)
+		assert.Equal(t, expected.booleans, capabilities.Booleans, "dialect=%T", dialect)
+		assert.Equal(t, expected.pagination, capabilities.Pagination, "dialect=%T", dialect)
+		assert.Equal(t, expected.ddl, capabilities.TransactionalDDL, "dialect=%T", dialect)
+		assert.Equal(t, expected.semicolons, capabilities.Semicolons, "dialect=%T", dialect)
+	}
+}
+
+func TestCapabilitiesOfWrapped(t *testing.T) {
+	type wrappedDialect struct {
+		Dialect
+	}
+
+	expected := defaultDialect{}.Capabilities()
+	expected.Upsert = UpsertUnsupported
+
+	assert.Equal(t, expected, CapabilitiesOf(wrappedDialect{sqliteDialect{}}))
+}
+
+func TestBooleanSyntax(t *testing.T) {
+	assert.Equal(t, "true", BooleanKeywords.Literal(true))
+	assert.Equal(t, "false", BooleanKeywords.Literal(false))
+	assert.Equal(t, "1", BooleanIntegers.Literal(true))
+	assert.Equal(t, "0", BooleanIntegers.Literal(false))
+}
+
+func TestSavepointSyntax(t *testing.T) {
+	for syntax, expected := range map[SavepointSyntax][3]string{
+		SavepointsUnsupported:    {"", "", ""},
+		SavepointsStandard:       {"savepoint s", "release savepoint s", "rollback to savepoint s"},
+		SavepointsWithoutRelease: {"savepoint s", "", "rollback to savepoint s"},
+		SavepointsTransact:       {"save transaction s", "", "rollback transaction s"},
+	} {
+		create, release, rollback := syntax.savepointStatements("s")
+		assert.Equal(t, expected, [3]string{create, release, rollback}, "syntax=%d", syntax)
+	}
+}
@@ -1,8 +1,21 @@
 package noorm
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnsupported is returned when a feature is not supported by a dialect (see Capabilities).
+	ErrUnsupported = errors.New("noorm: unsupported by dialect")
+	// ErrUnknownDialect is returned when no dialect is registered for a database driver.
+	ErrUnknownDialect = errors.New("noorm: unknown dialect")
 )
 
 // Dialect provides database specific sql query helpers.
@@ -14,20 +27,247 @@ type Dialect interface {
 	QuoteIdentifier(identifier string) string
 }
 
-func guessDialect(driverName string) Dialect {
-	switch strings.ToLower(driverName) {
-	case "sqlite3":
-		return sqliteDialect{}
+// UpsertDialect is an optional extension of Dialect to generate upsert queries (see Upsert).
+type UpsertDialect interface {
+	Dialect
+	// UpsertQuery returns a query, which inserts `values` into `columns` of a table. When a row
+	// conflicting on the `conflict` columns already exists, the `update` columns are updated
+	// instead. If there are no `update` columns, the conflicting row is left untouched.
+	// Table and column names are not yet quoted. Values are sql expressions, which can be used as
+	// is. The query must not be terminated by a semicolon.
+	UpsertQuery(table string, columns, values, conflict, update []string) string
+}
+
+// InsertDialect is an optional extension of Dialect to generate insert queries, which differ from
+// `insert into t (c) values (v), (v) returning *`.
+type InsertDialect interface {
+	Dialect
+	// InsertQuery returns a query, which inserts rows of values into columns of a table. If returning
+	// is true, the query returns the inserted rows. It is only true, if the dialect supports
+	// returning (see Capabilities). The number of rows is limited by the capabilities as well.
+	// Table and column names are not yet quoted. Values are sql expressions, which can be used as
+	// is. The query must not be terminated by a semicolon.
+	InsertQuery(table string, columns []string, rows [][]string, returning bool) string
+}
+
+// insertQuery returns a query, which inserts rows of values, without a trailing semicolon.
+func insertQuery(dialect Dialect, table string, columns []string, rows [][]string,
+	returning bool) string {
+	if insertDialect, ok := dialect.(InsertDialect); ok {
+		return insertDialect.InsertQuery(table, columns, rows, returning)
+	}
+
+	var buffer bytes.Buffer
+
+	writeInsert(&buffer, dialect, table, columns, rows...)
+
+	if returning {
+		buffer.WriteString(" returning *")
+	}
+
+	return buffer.String()
+}
+
+// Paginate returns a clause to append to a query, which skips offset rows and limits the query to
+// limit rows. Some databases require the query to be ordered (eg. SQL Server).
+// Example: `select * from "users" order by "id" ` + clause
+func Paginate(dialect Dialect, limit, offset int) (string, error) {
+	if limit < 1 || offset < 0 {
+		return "", fmt.Errorf("%w: invalid pagination limit %d offset %d", ErrInvalidArg, limit, offset)
+	}
+
+	if CapabilitiesOf(dialect).Pagination == PaginationOffsetFetch {
+		return "offset " + strconv.Itoa(offset) + " rows fetch next " + strconv.Itoa(limit) +
+			" rows only", nil
+	}
+
+	if offset == 0 {
+		return "limit " + strconv.Itoa(limit), nil
+	}
+
+	return "limit " + strconv.Itoa(limit) + " offset " + strconv.Itoa(offset), nil
+}
+
+// JSONDialect is an optional extension of Dialect to extract values from json documents (see
+// JSONPath).
+type JSONDialect interface {
+	Dialect
+	// JSONPath returns an sql expression, which extracts the value at path from the json document
+	// expr. Strings are unquoted. The path consists of object keys and array indices, which have
+	// been validated to only contain letters, digits, '-' and '_'. Elements consisting of digits
+	// only are array indices.
+	JSONPath(expr string, path []string) string
+}
+
+// JSONPath returns an sql expression, which extracts the value at path from the json document
+// expr (eg. a quoted column name). Strings are unquoted. Path elements consisting of digits only
+// are array indices, all others are object keys.
+// Example: JSONPath(dialect, `"document"`, "tags", "0") extracts the first tag.
+func JSONPath(dialect Dialect, expr string, path ...string) (string, error) {
+	jsonDialect, ok := dialect.(JSONDialect)
+	if !ok {
+		return "", fmt.Errorf("%w: json paths are not supported by %T", ErrUnsupported, dialect)
+	}
+
+	if len(path) == 0 {
+		return "", fmt.Errorf("%w: empty json path", ErrInvalidArg)
+	}
+
+	for _, element := range path {
+		if element == "" || !isValidFieldName(element) {
+			return "", fmt.Errorf("%w: invalid json path element %q", ErrInvalidArg, element)
+		}
+	}
+
+	return jsonDialect.JSONPath(expr, path), nil
+}
+
+func isJSONArrayIndex(element string) bool {
+	return strings.Trim(element, "0123456789") == ""
+}
+
+// jsonPathString returns the path in the syntax of sqlite, mysql, sql server and oracle
+// (eg. `'$."tags"[0]'`).
+func jsonPathString(path []string) string {
+	var builder strings.Builder
+
+	builder.WriteString("'$")
+
+	for _, element := range path {
+		if isJSONArrayIndex(element) {
+			builder.WriteString("[" + element + "]")
+		} else {
+			builder.WriteString(`."` + element + `"`)
+		}
+	}
+
+	builder.WriteString("'")
+	return builder.String()
+}
+
+var (
+	dialectsMutex sync.RWMutex
+	dialects      = map[string]Dialect{
+		"sqlite3":          sqliteDialect{},
+		"sqlite":           sqliteDialect{},
+		"postgres":         postgresDialect{},
+		"pgx":              postgresDialect{},
+		"cloudsqlpostgres": postgresDialect{},
+		"mysql":            mysqlDialect{},
+		"sqlserver":        sqlserverDialect{},
+		"mssql":            sqlserverDialect{},
+		"azuresql":         sqlserverDialect{},
+		"oracle":           oracleDialect{},
+		"godror":           oracleDialect{},
+	}
+
+	// driverTypes caches the types of drivers registered in database/sql by their name. Drivers
+	// cannot be unregistered, so the types never change.
+	driverTypes = map[string]reflect.Type{}
+)
+
+// RegisterDialect registers the dialect of a database driver by the name it is registered with in
+// database/sql. Dialects of the drivers `sqlite3`, `sqlite`, `postgres`, `pgx`, `cloudsqlpostgres`,
+// `mysql`, `sqlserver`, `mssql`, `azuresql`, `oracle` and `godror` are registered by default, but
+// may be replaced. Drivers without a dialect of their own use the dialect registered by their lower
+// case name (eg. `SQLite3` uses the dialect of `sqlite3`).
+// RegisterDialect panics, if the dialect is nil.
+func RegisterDialect(driverName string, dialect Dialect) {
+	if dialect == nil {
+		panic("noorm: RegisterDialect dialect is nil")
+	}
+
+	dialectsMutex.Lock()
+	defer dialectsMutex.Unlock()
+
+	dialects[driverName] = dialect
+}
+
+func lookupDialect(driverName string) (Dialect, bool) {
+	dialectsMutex.RLock()
+	defer dialectsMutex.RUnlock()
 
-	case "postgres":
-		return postgresDialect{}
+	if dialect, ok := dialects[driverName]; ok {
+		return dialect, true
+	}
+
+	dialect, ok := dialects[strings.ToLower(driverName)]
+	return dialect, ok
+}
 
-	case "mysql":
-		return mysqlDialect{}
+// detectDialect returns the registered dialect of the driver of db. The driver is identified by
+// comparing its type to the drivers registered in database/sql.
+func detectDialect(db *sql.DB) (Dialect, error) {
+	driverType := reflect.TypeOf(db.Driver())
 
-	default:
-		return defaultDialect{}
+	for _, driverName := range sql.Drivers() {
+		dialect, ok := lookupDialect(driverName)
+		if ok && driverTypeOf(driverName) == driverType {
+			return dialect, nil
+		}
 	}
+
+	return nil, fmt.Errorf("%w: no dialect registered for driver %q (see RegisterDialect)",
+		ErrUnknownDialect, driverType)
+}
+
+// driverTypeOf returns the type of a driver registered in database/sql. No connection is opened.
+func driverTypeOf(driverName string) reflect.Type {
+	dialectsMutex.RLock()
+	driverType, ok := driverTypes[driverName]
+	dialectsMutex.RUnlock()
+
+	if ok {
+		return driverType
+	}
+
+	if db, err := sql.Open(driverName, ""); err == nil {
+		driverType = reflect.TypeOf(db.Driver())
+		db.Close()
+	}
+
+	dialectsMutex.Lock()
+	defer dialectsMutex.Unlock()
+
+	driverTypes[driverName] = driverType
+	return driverType
+}
+
+// SQLite returns the dialect of SQLite.
+func SQLite() Dialect {
+	return sqliteDialect{}
+}
+
+// Postgres returns the dialect of PostgreSQL.
+func Postgres() Dialect {
+	return postgresDialect{}
+}
+
+// MySQL returns the dialect of MySQL and MariaDB.
+func MySQL() Dialect {
+	return mysqlDialect{}
+}
+
+// SQLServer returns the dialect of Microsoft SQL Server 2012 and later.
+func SQLServer() Dialect {
+	return sqlserverDialect{}
+}
+
+// Oracle returns the dialect of Oracle Database 12c and later.
+// Unquoted identifiers are folded to upper case by Oracle, so identifiers without upper case
+// letters are quoted in upper case (eg. `"USERS"` for `users`), while all others are quoted as is.
+// Because Oracle reports column names in upper case as well, the columns of a Database using this
+// dialect are usually matched case-insensitively (see Database.SetCaseInsensitiveColumns).
+// Oracle can only return inserted rows into output parameters, so InsertReturning selects the
+// inserted row by its primary key instead, which the model must provide.
+func Oracle() Dialect {
+	return oracleDialect{}
+}
+
+// DefaultDialect returns a generic dialect using `?` placeholders and double quoted identifiers.
+// It can be used explicitly for databases without a registered dialect.
+func DefaultDialect() Dialect {
+	return defaultDialect{}
 }
 
 type defaultDialect struct{}
@@ -40,22 +280,382 @@ func (defaultDialect) QuoteIdentifier(identifier string) string {
 	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
 }
 
+func (defaultDialect) Capabilities() Capabilities {
+	return Capabilities{
+		// the lowest common limit is the default of sqlite prior to 3.32.0
+		MaxParameters:     999,
+		Savepoints:        SavepointsStandard,
+		Upsert:            UpsertOnConflict,
+		Booleans:          BooleanKeywords,
+		Pagination:        PaginationLimitOffset,
+		CreateIfNotExists: true,
+		Semicolons:        true,
+	}
+}
+
+func (d defaultDialect) UpsertQuery(table string, columns, values, conflict, update []string) string {
+	var buffer bytes.Buffer
+
+	writeInsert(&buffer, d, table, columns, values)
+	buffer.WriteString(" on conflict (")
+	writeIdentifierList(&buffer, d, conflict)
+
+	if len(update) == 0 {
+		buffer.WriteString(") do nothing")
+		return buffer.String()
+	}
+
+	buffer.WriteString(") do update set ")
+
+	for i, column := range update {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		column = d.QuoteIdentifier(column)
+		buffer.WriteString(column)
+		buffer.WriteString(" = excluded.")
+		buffer.WriteString(column)
+	}
+
+	return buffer.String()
+}
+
 type sqliteDialect struct {
 	defaultDialect
 }
 
+func (sqliteDialect) syntax() sqlSyntax {
+	return sqlSyntax{
+		backtickIdentifiers: true,
+		bracketIdentifiers:  true,
+	}
+}
+
+func (sqliteDialect) Capabilities() Capabilities {
+	return Capabilities{
+		MaxParameters: 32766,
+		// since sqlite 3.35.0
+		Returning:         true,
+		Savepoints:        SavepointsStandard,
+		Upsert:            UpsertOnConflict,
+		Booleans:          BooleanKeywords,
+		Pagination:        PaginationLimitOffset,
+		TransactionalDDL:  true,
+		CreateIfNotExists: true,
+		Semicolons:        true,
+	}
+}
+
+func (sqliteDialect) JSONPath(expr string, path []string) string {
+	return "json_extract(" + expr + ", " + jsonPathString(path) + ")"
+}
+
 type postgresDialect struct {
 	defaultDialect
 }
 
+func (postgresDialect) syntax() sqlSyntax {
+	return sqlSyntax{
+		escapeStrings:  true,
+		dollarQuotes:   true,
+		nestedComments: true,
+	}
+}
+
 func (postgresDialect) Placeholder(position int) string {
 	return "$" + strconv.Itoa(position+1)
 }
 
+func (postgresDialect) Capabilities() Capabilities {
+	return Capabilities{
+		MaxParameters:     65535,
+		Returning:         true,
+		Savepoints:        SavepointsStandard,
+		Upsert:            UpsertOnConflict,
+		Booleans:          BooleanKeywords,
+		Pagination:        PaginationLimitOffset,
+		TransactionalDDL:  true,
+		CreateIfNotExists: true,
+		Semicolons:        true,
+	}
+}
+
+func (postgresDialect) JSONPath(expr string, path []string) string {
+	return "(" + expr + " #>> '{" + strings.Join(path, ",") + "}')"
+}
+
 type mysqlDialect struct {
 	defaultDialect
 }
 
+func (mysqlDialect) syntax() sqlSyntax {
+	return sqlSyntax{
+		backslashEscapes:      true,
+		doubleQuotedStrings:   true,
+		backtickIdentifiers:   true,
+		hashComments:          true,
+		dashCommentNeedsSpace: true,
+	}
+}
+
 func (mysqlDialect) QuoteIdentifier(identifier string) string {
 	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
 }
+
+func (mysqlDialect) Capabilities() Capabilities {
+	return Capabilities{
+		MaxParameters:     65535,
+		Savepoints:        SavepointsStandard,
+		Upsert:            UpsertOnDuplicateKey,
+		Booleans:          BooleanKeywords,
+		Pagination:        PaginationLimitOffset,
+		CreateIfNotExists: true,
+		Semicolons:        true,
+	}
+}
+
+func (mysqlDialect) JSONPath(expr string, path []string) string {
+	return "json_unquote(json_extract(" + expr + ", " + jsonPathString(path) + "))"
+}
+
+func (d mysqlDialect) UpsertQuery(table string, columns, values, conflict, update []string) string {
+	var buffer bytes.Buffer
+
+	writeInsert(&buffer, d, table, columns, values)
+	buffer.WriteString(" on duplicate key update ")
+
+	if len(update) == 0 {
+		// mysql has no syntax to ignore conflicts without also ignoring other errors,
+		// so a conflicting column is assigned to itself instead.
+		column := d.QuoteIdentifier(conflict[0])
+		buffer.WriteString(column)
+		buffer.WriteString(" = ")
+		buffer.WriteString(column)
+
+		return buffer.String()
+	}
+
+	for i, column := range update {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		column = d.QuoteIdentifier(column)
+		buffer.WriteString(column)
+		buffer.WriteString(" = values(")
+		buffer.WriteString(column)
+		buffer.WriteString(")")
+	}
+
+	return buffer.String()
+}
+
+type sqlserverDialect struct {
+	defaultDialect
+}
+
+func (sqlserverDialect) syntax() sqlSyntax {
+	return sqlSyntax{
+		bracketIdentifiers: true,
+		nestedComments:     true,
+	}
+}
+
+func (sqlserverDialect) Placeholder(position int) string {
+	return "@p" + strconv.Itoa(position+1)
+}
+
+func (sqlserverDialect) QuoteIdentifier(identifier string) string {
+	return "[" + strings.Replace(identifier, "]", "]]", -1) + "]"
+}
+
+func (sqlserverDialect) Capabilities() Capabilities {
+	return Capabilities{
+		// a request is limited to 2100 parameters including the statement and the declaration of
+		// the parameters passed to sp_executesql
+		MaxParameters: 2098,
+		// the limit of a table value constructor
+		MaxInsertRows:    1000,
+		Returning:        true,
+		Savepoints:       SavepointsTransact,
+		Upsert:           UpsertMerge,
+		Booleans:         BooleanIntegers,
+		Pagination:       PaginationOffsetFetch,
+		TransactionalDDL: true,
+		// merge statements must be terminated
+		Semicolons: true,
+	}
+}
+
+func (sqlserverDialect) JSONPath(expr string, path []string) string {
+	return "json_value(" + expr + ", " + jsonPathString(path) + ")"
+}
+
+func (d sqlserverDialect) InsertQuery(table string, columns []string, rows [][]string,
+	returning bool) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("insert into ")
+	buffer.WriteString(d.QuoteIdentifier(table))
+	buffer.WriteString(" (")
+	writeIdentifierList(&buffer, d, columns)
+	buffer.WriteString(")")
+
+	if returning {
+		buffer.WriteString(" output inserted.*")
+	}
+
+	buffer.WriteString(" values ")
+	writeValueRows(&buffer, rows)
+
+	return buffer.String()
+}
+
+func (d sqlserverDialect) UpsertQuery(table string, columns, values, conflict,
+	update []string) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("merge into ")
+	buffer.WriteString(d.QuoteIdentifier(table))
+	buffer.WriteString(" with (holdlock) as target using (values ")
+	writeValueRows(&buffer, [][]string{values})
+	buffer.WriteString(") as source (")
+	writeIdentifierList(&buffer, d, columns)
+	buffer.WriteString(")")
+	writeMergeClauses(&buffer, d, columns, conflict, update)
+
+	return buffer.String()
+}
+
+type oracleDialect struct {
+	defaultDialect
+}
+
+func (oracleDialect) syntax() sqlSyntax {
+	return sqlSyntax{
+		alternativeQuotes: true,
+	}
+}
+
+func (oracleDialect) Placeholder(position int) string {
+	return ":" + strconv.Itoa(position+1)
+}
+
+func (oracleDialect) QuoteIdentifier(identifier string) string {
+	if strings.ToLower(identifier) == identifier {
+		identifier = strings.ToUpper(identifier)
+	}
+
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+func (oracleDialect) Capabilities() Capabilities {
+	return Capabilities{
+		MaxParameters: 65535,
+		ReturningInto: true,
+		Savepoints:    SavepointsWithoutRelease,
+		Upsert:        UpsertMerge,
+		Booleans:      BooleanIntegers,
+		Pagination:    PaginationOffsetFetch,
+	}
+}
+
+func (oracleDialect) JSONPath(expr string, path []string) string {
+	return "json_value(" + expr + ", " + jsonPathString(path) + ")"
+}
+
+// InsertQuery inserts multiple rows with `insert all`, because multiple rows in a values clause
+// require Oracle 23ai. Returning rows is not supported, but returning into out parameters is (see
+// Capabilities).
+func (d oracleDialect) InsertQuery(table string, columns []string, rows [][]string, _ bool) string {
+	var buffer bytes.Buffer
+
+	if len(rows) == 1 {
+		writeInsert(&buffer, d, table, columns, rows...)
+		return buffer.String()
+	}
+
+	buffer.WriteString("insert all")
+
+	for _, row := range rows {
+		buffer.WriteString(" into ")
+		buffer.WriteString(d.QuoteIdentifier(table))
+		buffer.WriteString(" (")
+		writeIdentifierList(&buffer, d, columns)
+		buffer.WriteString(") values ")
+		writeValueRows(&buffer, [][]string{row})
+	}
+
+	buffer.WriteString(" select 1 from dual")
+	return buffer.String()
+}
+
+func (d oracleDialect) UpsertQuery(table string, columns, values, conflict,
+	update []string) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("merge into ")
+	buffer.WriteString(d.QuoteIdentifier(table))
+	buffer.WriteString(" target using (select ")
+
+	for i, column := range columns {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		buffer.WriteString(values[i])
+		buffer.WriteString(" ")
+		buffer.WriteString(d.QuoteIdentifier(column))
+	}
+
+	buffer.WriteString(" from dual) source")
+	writeMergeClauses(&buffer, d, columns, conflict, update)
+
+	return buffer.String()
+}
+
+// writeMergeClauses writes the clauses of a merge query following `using (...) source`, which
+// update the `target` row matching the `source` row on the conflict columns or insert the source
+// row otherwise.
+func writeMergeClauses(buffer *bytes.Buffer, dialect Dialect, columns, conflict, update []string) {
+	buffer.WriteString(" on (")
+
+	for i, column := range conflict {
+		if i > 0 {
+			buffer.WriteString(" and ")
+		}
+
+		column = dialect.QuoteIdentifier(column)
+		buffer.WriteString("target." + column + " = source." + column)
+	}
+
+	buffer.WriteString(")")
+
+	if len(update) > 0 {
+		buffer.WriteString(" when matched then update set ")
+
+		for i, column := range update {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+
+			column = dialect.QuoteIdentifier(column)
+			buffer.WriteString("target." + column + " = source." + column)
+		}
+	}
+
+	buffer.WriteString(" when not matched then insert (")
+	writeIdentifierList(buffer, dialect, columns)
+	buffer.WriteString(") values (")
+
+	for i, column := range columns {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		buffer.WriteString("source." + dialect.QuoteIdentifier(column))
+	}
+
+	buffer.WriteString(")")
+}
@@ -1,29 +1,123 @@
 package noorm
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestGuessDialect(t *testing.T) {
+func TestLookupDialect(t *testing.T) {
 	for driverName, expectedDialect := range map[string]Dialect{
-		"sqlite3":  sqliteDialect{},
-		"postgres": postgresDialect{},
-		"mysql":    mysqlDialect{},
-		"sql":      defaultDialect{},
+		"sqlite3":          sqliteDialect{},
+		"sqlite":           sqliteDialect{},
+		"postgres":         postgresDialect{},
+		"pgx":              postgresDialect{},
+		"cloudsqlpostgres": postgresDialect{},
+		"mysql":            mysqlDialect{},
+		"SQLite3":          sqliteDialect{},
+		"Postgres":         postgresDialect{},
+		"MySQL":            mysqlDialect{},
 	} {
-		actualDialect := guessDialect(driverName)
+		actualDialect, ok := lookupDialect(driverName)
+		assert.True(t, ok, "driverName=%q", driverName)
 		assert.IsTypef(t, expectedDialect, actualDialect, "driverName=%q", driverName)
 	}
+
+	_, ok := lookupDialect("sql")
+	assert.False(t, ok)
+}
+
+type testDriver struct{}
+
+var registerTestDriver sync.Once
+
+func (testDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRegisterDialect(t *testing.T) {
+	const driverName = "noorm_test_driver"
+
+	registerTestDriver.Do(func() { sql.Register(driverName, testDriver{}) })
+
+	t.Cleanup(func() {
+		dialectsMutex.Lock()
+		defer dialectsMutex.Unlock()
+
+		delete(dialects, driverName)
+	})
+
+	_, err := Open(driverName, "")
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+
+	sqlDB, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+
+	defer sqlDB.Close()
+
+	db := New(sqlDB, nil)
+	ctx := WithDatabase(context.Background(), db)
+
+	_, _, err = QuerierFrom(ctx)
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+
+	_, _, err = Begin(ctx, nil)
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+
+	_, err = Exec(ctx, SQL{Query: "select 1 ;"})
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+
+	RegisterDialect(driverName, Postgres())
+
+	db = New(sqlDB, nil)
+	_, dialect, err := QuerierFrom(WithDatabase(context.Background(), db))
+	require.NoError(t, err)
+	assert.IsType(t, postgresDialect{}, dialect)
+
+	db, err = Open(driverName, "")
+	require.NoError(t, err)
+	assert.IsType(t, postgresDialect{}, db.dialect)
+
+	assert.Panics(t, func() { RegisterDialect(driverName, nil) })
+}
+
+func TestDetectDialect(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	defer sqlDB.Close()
+
+	db := New(sqlDB, nil)
+	require.NoError(t, db.err)
+	assert.IsType(t, sqliteDialect{}, db.dialect)
+
+	// the driver types are cached, so that detecting the dialect again does not open any database
+	dialectsMutex.RLock()
+	driverType, ok := driverTypes["sqlite3"]
+	dialectsMutex.RUnlock()
+
+	require.True(t, ok)
+	assert.Equal(t, reflect.TypeOf(sqlDB.Driver()), driverType)
+
+	db = New(sqlDB, MySQL())
+	assert.IsType(t, mysqlDialect{}, db.dialect)
 }
 
 func TestDialectPlaceholder(t *testing.T) {
 	for dialect, expectedPlaceholders := range map[Dialect][]string{
-		sqliteDialect{}:   {"?", "?"},
-		postgresDialect{}: {"$1", "$2"},
-		mysqlDialect{}:    {"?", "?"},
-		defaultDialect{}:  {"?", "?"},
+		sqliteDialect{}:    {"?", "?"},
+		postgresDialect{}:  {"$1", "$2"},
+		mysqlDialect{}:     {"?", "?"},
+		sqlserverDialect{}: {"@p1", "@p2"},
+		oracleDialect{}:    {":1", ":2"},
+		defaultDialect{}:   {"?", "?"},
 	} {
 		actualPlaceholders := make([]string, 2)
 		for i := range actualPlaceholders {
@@ -36,10 +130,12 @@ func TestDialectPlaceholder(t *testing.T) {
 
 func TestDialectQuote(t *testing.T) {
 	for dialect, expectedQuoted := range map[Dialect][]string{
-		sqliteDialect{}:   {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
-		postgresDialect{}: {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
-		mysqlDialect{}:    {"`simple`", "`with \"double\" quotes`", "`with ``back`` ticks`"},
-		defaultDialect{}:  {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
+		sqliteDialect{}:    {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
+		postgresDialect{}:  {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
+		mysqlDialect{}:     {"`simple`", "`with \"double\" quotes`", "`with ``back`` ticks`"},
+		sqlserverDialect{}: {"[simple]", `[with "double" quotes]`, "[with `back` ticks]"},
+		oracleDialect{}:    {`"SIMPLE"`, `"WITH ""DOUBLE"" QUOTES"`, "\"WITH `BACK` TICKS\""},
+		defaultDialect{}:   {`"simple"`, `"with ""double"" quotes"`, "\"with `back` ticks\""},
 	} {
 		actualQuoted := []string{
 			dialect.QuoteIdentifier("simple"),
@@ -50,3 +146,63 @@ func TestDialectQuote(t *testing.T) {
 		assert.Equal(t, expectedQuoted, actualQuoted)
 	}
 }
+
+func TestDialectExtensions(t *testing.T) {
+	for dialect, expected := range map[Dialect]struct {
+		maxParameters int
+		returning     bool
+		returningInto bool
+	}{
+		sqliteDialect{}:    {maxParameters: 32766, returning: true},
+		postgresDialect{}:  {maxParameters: 65535, returning: true},
+		mysqlDialect{}:     {maxParameters: 65535},
+		sqlserverDialect{}: {maxParameters: 2098, returning: true},
+		oracleDialect{}:    {maxParameters: 65535, returningInto: true},
+		defaultDialect{}:   {maxParameters: 999},
+	} {
+		capabilities := CapabilitiesOf(dialect)
+		assert.Equal(t, expected.maxParameters, capabilities.MaxParameters, "dialect=%T", dialect)
+		assert.Equal(t, expected.returning, capabilities.Returning, "dialect=%T", dialect)
+		assert.Equal(t, expected.returningInto, capabilities.ReturningInto, "dialect=%T", dialect)
+	}
+}
+
+func TestDialectQuoteCase(t *testing.T) {
+	assert.Equal(t, "[with [brackets]]]", sqlserverDialect{}.QuoteIdentifier("with [brackets]"))
+
+	// oracle folds unquoted identifiers to upper case
+	assert.Equal(t, `"FIRST_NAME"`, oracleDialect{}.QuoteIdentifier("first_name"))
+	assert.Equal(t, `"FIRST_NAME"`, oracleDialect{}.QuoteIdentifier("FIRST_NAME"))
+	assert.Equal(t, `"FirstName"`, oracleDialect{}.QuoteIdentifier("FirstName"))
+}
+
+func TestPaginate(t *testing.T) {
+	type unpaginatedDialect struct {
+		Dialect
+	}
+
+	limitOffset := []string{"limit 10", "limit 10 offset 20"}
+	fetch := []string{"offset 0 rows fetch next 10 rows only", "offset 20 rows fetch next 10 rows only"}
+
+	for dialect, expected := range map[Dialect][]string{
+		sqliteDialect{}:                      limitOffset,
+		postgresDialect{}:                    limitOffset,
+		mysqlDialect{}:                       limitOffset,
+		sqlserverDialect{}:                   fetch,
+		oracleDialect{}:                      fetch,
+		unpaginatedDialect{defaultDialect{}}: limitOffset,
+	} {
+		first, err := Paginate(dialect, 10, 0)
+		require.NoError(t, err)
+
+		second, err := Paginate(dialect, 10, 20)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, []string{first, second}, "dialect=%T", dialect)
+	}
+
+	for _, tc := range [][2]int{{0, 0}, {-1, 0}, {10, -1}} {
+		_, err := Paginate(defaultDialect{}, tc[0], tc[1])
+		assert.ErrorIs(t, err, ErrInvalidArg, "limit=%d offset=%d", tc[0], tc[1])
+	}
+}
@@ -0,0 +1,227 @@
+package noorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var errNoOutcome = errors.New("noorm: interceptor returned neither an outcome nor an error")
+
+// StatementKind distinguishes statements returning rows from statements without rows.
+type StatementKind uint8
+
+const (
+	// StatementExec is a statement without rows executed by Exec or one of its variants.
+	StatementExec StatementKind = iota
+	// StatementQuery is a statement returning rows executed by Iterate or one of its variants.
+	StatementQuery
+)
+
+func (k StatementKind) String() string {
+	if k == StatementQuery {
+		return "query"
+	}
+
+	return "exec"
+}
+
+// Statement is a single statement passed through the interceptors of a Database.
+type Statement struct {
+	// Kind tells statements returning rows apart from statements without rows.
+	Kind StatementKind
+	// Source is the query source passed to noorm (eg. SQL with its named query or Insert).
+	// Query sources consisting of multiple queries (see InsertBatch) and InsertReturning may
+	// result in multiple statements sharing the same source.
+	Source QuerySource
+	// Query is the rebound sql query using the placeholders of the dialect.
+	Query string
+	// Params are the parameters of the query.
+	Params []any
+}
+
+// Outcome is the outcome of executing a Statement. If the execution failed, only the Duration is
+// set.
+type Outcome struct {
+	// Result is the result of a statement of kind StatementExec.
+	Result sql.Result
+	// Rows are the rows of a statement of kind StatementQuery. Reading the rows is not part of the
+	// Duration.
+	Rows *sql.Rows
+	// Duration is the time spent by the database executing the statement.
+	Duration time.Duration
+}
+
+// RowsAffected returns the number of rows affected by a statement of kind StatementExec or -1, if
+// the number is unknown.
+func (o Outcome) RowsAffected() int64 {
+	if o.Result == nil {
+		return -1
+	}
+
+	rowsAffected, err := o.Result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+
+	return rowsAffected
+}
+
+// Handler executes a statement.
+type Handler func(ctx context.Context, stmt *Statement) (Outcome, error)
+
+// Interceptor wraps the execution of statements (eg. for logging, metrics or tracing).
+// It may modify the statement before passing it to next, veto the statement by returning an error
+// without calling next and inspect or replace the outcome and error returned by next.
+type Interceptor func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error)
+
+// SetInterceptors sets the interceptors, which wrap every statement of the database including
+// statements within transactions. The first interceptor is the outermost one. Statements beginning
+// or ending transactions and savepoints are not intercepted.
+func (db *Database) SetInterceptors(interceptors ...Interceptor) {
+	interceptors = append([]Interceptor(nil), interceptors...)
+	db.interceptors.Store(&interceptors)
+}
+
+// execStatement executes a statement without rows through the interceptors of the database.
+func (db *Database) execStatement(ctx context.Context, querier Querier, source QuerySource,
+	query string, params []any) (sql.Result, error) {
+	outcome, err := db.intercept(ctx, querier, &Statement{
+		Kind:   StatementExec,
+		Source: source,
+		Query:  query,
+		Params: params,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome.Result == nil {
+		return nil, errNoOutcome
+	}
+
+	return outcome.Result, nil
+}
+
+// queryStatement executes a statement returning rows through the interceptors of the database.
+func (db *Database) queryStatement(ctx context.Context, querier Querier, source QuerySource,
+	query string, params []any) (*sql.Rows, error) {
+	outcome, err := db.intercept(ctx, querier, &Statement{
+		Kind:   StatementQuery,
+		Source: source,
+		Query:  query,
+		Params: params,
+	})
+
+	if err != nil {
+		if outcome.Rows != nil {
+			outcome.Rows.Close()
+		}
+
+		return nil, err
+	}
+
+	if outcome.Rows == nil {
+		return nil, errNoOutcome
+	}
+
+	return outcome.Rows, nil
+}
+
+func (db *Database) intercept(ctx context.Context, querier Querier,
+	stmt *Statement) (Outcome, error) {
+	handler := func(ctx context.Context, stmt *Statement) (Outcome, error) {
+		return executeStatement(ctx, querier, stmt)
+	}
+
+	if interceptors := db.interceptors.Load(); interceptors != nil {
+		for i := len(*interceptors) - 1; i >= 0; i-- {
+			handler = chainInterceptor((*interceptors)[i], handler)
+		}
+	}
+
+	return handler(ctx, stmt)
+}
+
+func chainInterceptor(interceptor Interceptor, next Handler) Handler {
+	return func(ctx context.Context, stmt *Statement) (Outcome, error) {
+		return interceptor(ctx, stmt, next)
+	}
+}
+
+// executeStatement is the innermost handler, which executes the statement using the querier.
+func executeStatement(ctx context.Context, querier Querier, stmt *Statement) (Outcome, error) {
+	var (
+		outcome Outcome
+		err     error
+		start   = time.Now()
+	)
+
+	if stmt.Kind == StatementQuery {
+		outcome.Rows, err = querier.QueryContext(ctx, stmt.Query, stmt.Params...)
+	} else {
+		outcome.Result, err = querier.ExecContext(ctx, stmt.Query, stmt.Params...)
+	}
+
+	outcome.Duration = time.Since(start)
+	return outcome, err
+}
+
+// LogInterceptor returns an interceptor, which logs every statement at the level and failed
+// statements at error level. The parameters are not logged, because they may contain sensitive
+// data. If the logger is nil, slog.Default() is used.
+func LogInterceptor(logger *slog.Logger, level slog.Level) Interceptor {
+	return func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+		outcome, err := next(ctx, stmt)
+
+		if err != nil {
+			logStatement(ctx, logger, slog.LevelError, "noorm: statement failed", stmt, outcome,
+				slog.Any("error", err))
+		} else {
+			logStatement(ctx, logger, level, "noorm: statement", stmt, outcome)
+		}
+
+		return outcome, err
+	}
+}
+
+// SlowQueryInterceptor returns an interceptor, which logs statements taking at least the threshold
+// at warn level. If the logger is nil, slog.Default() is used.
+func SlowQueryInterceptor(logger *slog.Logger, threshold time.Duration) Interceptor {
+	return func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+		outcome, err := next(ctx, stmt)
+
+		if outcome.Duration >= threshold {
+			logStatement(ctx, logger, slog.LevelWarn, "noorm: slow statement", stmt, outcome,
+				slog.Duration("threshold", threshold))
+		}
+
+		return outcome, err
+	}
+}
+
+func logStatement(ctx context.Context, logger *slog.Logger, level slog.Level, msg string,
+	stmt *Statement, outcome Outcome, attrs ...slog.Attr) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs = append(attrs,
+		slog.String("kind", stmt.Kind.String()),
+		slog.String("query", stmt.Query),
+		slog.Duration("duration", outcome.Duration),
+	)
+
+	if stmt.Kind == StatementExec && outcome.Result != nil {
+		attrs = append(attrs, slog.Int64("rows_affected", outcome.RowsAffected()))
+	}
+
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,179 @@
+package noorm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInterceptorTest(t *testing.T) (context.Context, *Database) {
+	db, err := Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	ctx := WithDatabase(context.Background(), db)
+
+	_, err = Exec(ctx, SQL{Query: `create table "users" ( "id" integer, "name" varchar ) ;`})
+	require.NoError(t, err)
+
+	return ctx, db
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx, db := setupInterceptorTest(t)
+
+	var (
+		calls      []string
+		statements []Statement
+		outcomes   []Outcome
+	)
+
+	db.SetInterceptors(
+		func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+			calls = append(calls, "outer")
+			return next(ctx, stmt)
+		},
+		func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+			calls = append(calls, "inner")
+
+			outcome, err := next(ctx, stmt)
+			statements = append(statements, *stmt)
+			outcomes = append(outcomes, outcome)
+
+			return outcome, err
+		},
+	)
+
+	insert := Insert{Tablename: "users", Model: testStructUser{ID: 1, Name: "a"}}
+	_, err := Exec(ctx, insert)
+	require.NoError(t, err)
+
+	query := SQL{Query: `select * from "users" where "id" = @0 ;`, Args: Positional(1)}
+	users, err := Query[testStructUser](ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, []testStructUser{{ID: 1, Name: "a"}}, users)
+
+	assert.Equal(t, []string{"outer", "inner", "outer", "inner"}, calls)
+	assert.Equal(t, []Statement{
+		{
+			Kind:   StatementExec,
+			Source: insert,
+			Query:  `insert into "users" ("id", "name") values (?, ?) ;`,
+			Params: []any{1, "a"},
+		},
+		{
+			Kind:   StatementQuery,
+			Source: query,
+			Query:  `select * from "users" where "id" = ? ;`,
+			Params: []any{1},
+		},
+	}, statements)
+
+	assert.Equal(t, int64(1), outcomes[0].RowsAffected())
+	assert.Equal(t, int64(-1), outcomes[1].RowsAffected())
+	assert.NotNil(t, outcomes[1].Rows)
+}
+
+func TestInterceptorsModifyAndVeto(t *testing.T) {
+	ctx, db := setupInterceptorTest(t)
+
+	errTenant := errors.New("tenant guard")
+
+	db.SetInterceptors(func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+		if stmt.Kind == StatementExec && stmt.Params[1] == "forbidden" {
+			return Outcome{}, errTenant
+		}
+
+		stmt.Params[1] = "modified"
+		return next(ctx, stmt)
+	})
+
+	_, err := Exec(ctx, Insert{Tablename: "users", Model: testStructUser{ID: 1, Name: "forbidden"}})
+	assert.ErrorIs(t, err, errTenant)
+
+	ctx, tx, err := Begin(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = Exec(ctx, Insert{Tablename: "users", Model: testStructUser{ID: 2, Name: "b"}})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	db.SetInterceptors()
+
+	users, err := Query[testStructUser](WithDatabase(context.Background(), db), SQL{
+		Query: `select * from "users" ;`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []testStructUser{{ID: 2, Name: "modified"}}, users)
+}
+
+func TestInterceptorsNoOutcome(t *testing.T) {
+	ctx, db := setupInterceptorTest(t)
+
+	db.SetInterceptors(func(context.Context, *Statement, Handler) (Outcome, error) {
+		return Outcome{}, nil
+	})
+
+	_, err := Exec(ctx, SQL{Query: `delete from "users" ;`})
+	assert.ErrorIs(t, err, errNoOutcome)
+
+	_, err = Query[testStructUser](ctx, SQL{Query: `select * from "users" ;`})
+	assert.ErrorIs(t, err, errNoOutcome)
+}
+
+func TestLogInterceptor(t *testing.T) {
+	ctx, db := setupInterceptorTest(t)
+
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "duration" {
+				return slog.Attr{}
+			}
+
+			return attr
+		},
+	}))
+
+	db.SetInterceptors(LogInterceptor(logger, slog.LevelInfo))
+
+	_, err := Exec(ctx, SQL{Query: `delete from "users" ;`})
+	require.NoError(t, err)
+
+	_, err = Exec(ctx, SQL{Query: `delete from "unknown" ;`})
+	require.Error(t, err)
+
+	assert.Equal(t,
+		`level=INFO msg="noorm: statement" kind=exec query="delete from \"users\" ;" rows_affected=0`+
+			"\n"+
+			`level=ERROR msg="noorm: statement failed" error="no such table: unknown" kind=exec`+
+			` query="delete from \"unknown\" ;"`+"\n",
+		buffer.String())
+}
+
+func TestSlowQueryInterceptor(t *testing.T) {
+	ctx, db := setupInterceptorTest(t)
+
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, nil))
+
+	db.SetInterceptors(SlowQueryInterceptor(logger, time.Hour))
+
+	_, err := Query[testStructUser](ctx, SQL{Query: `select * from "users" ;`})
+	require.NoError(t, err)
+	assert.Empty(t, buffer.String())
+
+	db.SetInterceptors(SlowQueryInterceptor(logger, 0))
+
+	_, err = Query[testStructUser](ctx, SQL{Query: `select * from "users" ;`})
+	require.NoError(t, err)
+	assert.Contains(t, buffer.String(), `level=WARN msg="noorm: slow statement" threshold=0s kind=query`)
+}
@@ -2,30 +2,30 @@ package noorm
 
 import (
 	"database/sql"
+	"fmt"
 	"reflect"
+	"time"
 )
 
-type iterator[T Struct] struct {
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+type iterator[T any] struct {
 	*sql.Rows
-	columnNames []string
-	columnIndex fieldLookupMap
+	scanner rowScanner
 }
 
-func newIterator[T Struct](rows *sql.Rows) (Iterator[T], error) {
-	columnNames, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-
-	columnIndex, err := buildFieldLookupMap[T]()
+func newIterator[T any](rows *sql.Rows, strict StrictMode, naming *naming) (Iterator[T], error) {
+	scanner, err := newRowScanner(typeOfGeneric[T](), rows, strict, naming)
 	if err != nil {
 		return nil, err
 	}
 
 	iter := iterator[T]{
-		Rows:        rows,
-		columnNames: columnNames,
-		columnIndex: columnIndex,
+		Rows:    rows,
+		scanner: scanner,
 	}
 
 	return &iter, nil
@@ -38,13 +38,138 @@ func (i iterator[T]) Value() (T, error) {
 }
 
 func (i iterator[T]) scanInto(target *T) error {
-	value := reflect.Indirect(reflect.ValueOf(target))
+	return i.scanner.scan(i.Rows, reflect.ValueOf(target).Elem())
+}
+
+// rowScanner scans the current row into an addressable value.
+type rowScanner interface {
+	scan(rows *sql.Rows, value reflect.Value) error
+}
 
-	targetSlice, err := buildScanTargetSlice(i.columnIndex, i.columnNames, value)
+// newRowScanner returns a scanner for the rows into t. The strict mode and naming only apply to
+// structs.
+func newRowScanner(t reflect.Type, rows *sql.Rows, strict StrictMode,
+	naming *naming) (rowScanner, error) {
+	switch t {
+	case recordType:
+		return newRecordScanner(rows, false)
+
+	case mapType:
+		return newRecordScanner(rows, true)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	if isScalarType(t) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("%w: cannot scan %d columns into scalar %q",
+				ErrInvalidTargetType, len(columns), t)
+		}
+
+		return scalarScanner{}, nil
+	}
+
+	if t.Kind() == reflect.Pointer {
+		elem, err := newRowScanner(t.Elem(), rows, strict, naming)
+		if err != nil {
+			return nil, err
+		}
+
+		return pointerScanner{elem: elem}, nil
+	}
+
+	lookup, columns, err := naming.columnLookup(t, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkStrictMapping(t, lookup, columns, strict); err != nil {
+		return nil, err
+	}
+
+	return structScanner{plan: naming.scanPlan(t, lookup, columns)}, nil
+}
+
+// isScalarType reports whether a type is scanned from a single column instead of being mapped
+// field by field. These are all types except structs, but including time.Time and structs
+// implementing sql.Scanner.
+func isScalarType(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(scannerType) {
+		return true
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return isScalarType(t.Elem())
+	}
+
+	return t.Kind() != reflect.Struct || t == timeType
+}
+
+type scalarScanner struct{}
+
+func (scalarScanner) scan(rows *sql.Rows, value reflect.Value) error {
+	return rows.Scan(value.Addr().Interface())
+}
+
+// pointerScanner allocates a new value for every row, which is scanned by the elem scanner.
+type pointerScanner struct {
+	elem rowScanner
+}
+
+func (s pointerScanner) scan(rows *sql.Rows, value reflect.Value) error {
+	elem := reflect.New(value.Type().Elem())
+	if err := s.elem.scan(rows, elem.Elem()); err != nil {
+		return err
+	}
+
+	value.Set(elem)
+	return nil
+}
+
+type structScanner struct {
+	plan *scanPlan
+}
+
+func (s structScanner) scan(rows *sql.Rows, value reflect.Value) error {
+	return s.plan.scan(rows, value)
+}
+
+// scanFirst scans the first row into the struct pointed to by target and closes the rows.
+// If there are no rows, sql.ErrNoRows is returned.
+func scanFirst(rows *sql.Rows, target reflect.Value, strict StrictMode, naming *naming) error {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	t := target.Type().Elem()
+
+	lookup, columns, err := naming.columnLookup(t, columns)
 	if err != nil {
 		return err
 	}
 
-	return i.Scan(targetSlice...)
+	if err := checkStrictMapping(t, lookup, columns, strict); err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return sql.ErrNoRows
+	}
+
+	scanner := structScanner{plan: naming.scanPlan(t, lookup, columns)}
+	if err := scanner.scan(rows, target.Elem()); err != nil {
+		return err
+	}
 
+	return rows.Close()
 }
@@ -0,0 +1,100 @@
+package noorm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsScalarType(t *testing.T) {
+	for _, tc := range []struct {
+		value  any
+		scalar bool
+	}{
+		{value: int64(0), scalar: true},
+		{value: "", scalar: true},
+		{value: false, scalar: true},
+		{value: []byte{}, scalar: true},
+		{value: time.Time{}, scalar: true},
+		{value: sql.NullString{}, scalar: true},
+		{value: new(int), scalar: true},
+		{value: new(time.Time), scalar: true},
+		{value: testStructUser{}, scalar: false},
+		{value: &testStructUser{}, scalar: false},
+	} {
+		assert.Equal(t, tc.scalar, isScalarType(reflect.TypeOf(tc.value)), "type=%T", tc.value)
+	}
+}
+
+func TestNewRowScanner(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	for _, tc := range []struct {
+		target  reflect.Type
+		query   string
+		scanner rowScanner
+		err     error
+	}{
+		{target: reflect.TypeOf(0), query: "select 1 as a, 2 as b", err: ErrInvalidTargetType},
+		{target: reflect.TypeOf(0), query: "select 1 as a", scanner: scalarScanner{}},
+		{target: reflect.TypeOf(testStructUser{}), query: "select 1 as id, 'Foo' as name", scanner: structScanner{}},
+		{target: reflect.TypeOf(&testStructUser{}), query: "select 1 as id, 'Foo' as name", scanner: pointerScanner{}},
+		{target: reflect.TypeOf(Record{}), query: "select 1 as a, 2 as b", scanner: recordScanner{}},
+		{target: reflect.TypeOf(map[string]any{}), query: "select 1 as a, 2 as b", scanner: recordScanner{}},
+	} {
+		rows, err := db.Query(tc.query)
+		require.NoError(t, err)
+
+		scanner, err := newRowScanner(tc.target, rows, 0, defaultNaming)
+		rows.Close()
+
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, "target=%v", tc.target)
+		} else {
+			assert.NoError(t, err, "target=%v", tc.target)
+			assert.IsType(t, tc.scanner, scanner, "target=%v", tc.target)
+		}
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	db, err := Open("sqlite3", ":memory:")
+	require.NoError(b, err)
+
+	defer db.Close()
+
+	_, err = db.Exec(`
+		create table "users" ( "id" integer primary key , "name" varchar not null ) ;
+
+		with recursive "numbers" ( "n" ) as (
+			select 1 union all select "n" + 1 from "numbers" where "n" < 100
+		)
+		insert into "users" ( "name" ) select 'User ' || "n" from "numbers" ;
+	`)
+	require.NoError(b, err)
+
+	ctx := WithDatabase(context.Background(), db)
+	query := SQL{Query: `select * from "users" ;`}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		users, err := Query[testStructUser](ctx, query)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if len(users) != 100 {
+			b.Fatalf("expected 100 users, got %d", len(users))
+		}
+	}
+}
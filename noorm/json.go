@@ -0,0 +1,74 @@
+package noorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	_ sql.Scanner      = &JSON[any]{}
+	_ driver.Valuer    = JSON[any]{}
+	_ json.Marshaler   = JSON[any]{}
+	_ json.Unmarshaler = &JSON[any]{}
+)
+
+// JSON is a value stored as json document (eg. in `jsonb` columns of Postgres, `json` columns of
+// MySQL or `text` columns of SQLite). The value is marshalled when bound as query parameter and
+// unmarshalled when scanned. A null column is scanned as zero value. Use Null[JSON[T]] to
+// distinguish null columns.
+// JSON is encoded as V itself, when used within other json documents.
+type JSON[T any] struct {
+	V T
+}
+
+// Scan implements sql.Scanner.
+func (j *JSON[T]) Scan(src any) error {
+	var data []byte
+
+	switch src := src.(type) {
+	case nil:
+		*j = JSON[T]{}
+		return nil
+
+	case string:
+		data = []byte(src)
+
+	case []byte:
+		data = src
+
+	default:
+		return fmt.Errorf("%w: cannot scan %T into %T, expected string or []byte",
+			ErrInvalidTargetType, src, j)
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	j.V = v
+	return nil
+}
+
+// Value implements driver.Valuer. The document is bound as string, because some drivers bind
+// []byte as binary data, which is not accepted for json columns.
+func (j JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.V)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.V)
+}
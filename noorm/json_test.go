@@ -0,0 +1,74 @@
+package noorm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDocument struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+func TestJSONScan(t *testing.T) {
+	expected := JSON[testDocument]{V: testDocument{Title: "Foo", Tags: []string{"a", "b"}}}
+
+	for _, src := range []any{
+		`{"title": "Foo", "tags": ["a", "b"]}`,
+		[]byte(`{"title": "Foo", "tags": ["a", "b"]}`),
+	} {
+		var document JSON[testDocument]
+		require.NoError(t, document.Scan(src), "src=%#v", src)
+		assert.Equal(t, expected, document, "src=%#v", src)
+	}
+
+	document := expected
+	require.NoError(t, document.Scan(nil))
+	assert.Equal(t, JSON[testDocument]{}, document)
+
+	assert.ErrorIs(t, document.Scan(42), ErrInvalidTargetType)
+	assert.Error(t, document.Scan(`{"title": 42}`))
+}
+
+func TestJSONValue(t *testing.T) {
+	value, err := JSON[testDocument]{V: testDocument{Title: "Foo"}}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"Foo","tags":null}`, value)
+
+	data, err := json.Marshal(struct {
+		Document JSON[testDocument] `json:"document"`
+	}{
+		Document: JSON[testDocument]{V: testDocument{Title: "Foo"}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"document": {"title": "Foo", "tags": null}}`, string(data))
+
+	var document JSON[testDocument]
+	require.NoError(t, json.Unmarshal([]byte(`{"title": "Bar"}`), &document))
+	assert.Equal(t, "Bar", document.V.Title)
+}
+
+func TestJSONPath(t *testing.T) {
+	for dialect, expected := range map[Dialect]string{
+		sqliteDialect{}:    `json_extract("doc", '$."tags"[0]')`,
+		postgresDialect{}:  `("doc" #>> '{tags,0}')`,
+		mysqlDialect{}:     `json_unquote(json_extract("doc", '$."tags"[0]'))`,
+		sqlserverDialect{}: `json_value("doc", '$."tags"[0]')`,
+		oracleDialect{}:    `json_value("doc", '$."tags"[0]')`,
+	} {
+		path, err := JSONPath(dialect, `"doc"`, "tags", "0")
+		require.NoError(t, err, "dialect=%T", dialect)
+		assert.Equal(t, expected, path, "dialect=%T", dialect)
+	}
+
+	_, err := JSONPath(defaultDialect{}, `"doc"`, "tags")
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	for _, path := range [][]string{nil, {""}, {"it's"}, {"a.b"}} {
+		_, err := JSONPath(sqliteDialect{}, `"doc"`, path...)
+		assert.ErrorIs(t, err, ErrInvalidArg, "path=%q", path)
+	}
+}
@@ -0,0 +1,163 @@
+package noorm
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// NameMapper maps struct fields to column names, if their `db` tag does not declare a name.
+// The mapper of a database is applied when scanning rows, to Named arguments and by the query
+// generators (see Database.SetNameMapper).
+type NameMapper func(field reflect.StructField) string
+
+var (
+	// IdentityNames uses the field name as is (eg. `UserID`). This is the default.
+	IdentityNames NameMapper = func(field reflect.StructField) string {
+		return field.Name
+	}
+
+	// LowerCaseNames uses the lower-cased field name (eg. `userid`).
+	LowerCaseNames NameMapper = func(field reflect.StructField) string {
+		return strings.ToLower(field.Name)
+	}
+
+	// SnakeCaseNames uses the snake-cased field name (eg. `user_id`). Acronyms are kept together
+	// (eg. `HTTPServer` is mapped to `http_server`).
+	SnakeCaseNames NameMapper = func(field reflect.StructField) string {
+		return toSnakeCase(field.Name)
+	}
+)
+
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+
+	var builder strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				builder.WriteRune('_')
+			}
+		}
+
+		builder.WriteRune(unicode.ToLower(r))
+	}
+
+	return builder.String()
+}
+
+// defaultNaming is used by databases without a name mapper.
+var defaultNaming = newNaming(IdentityNames, false)
+
+// naming is the mapping of struct fields to columns of a database. It caches the analyzed struct
+// types and scan plans, which depend on the mapping.
+type naming struct {
+	mapper NameMapper
+	// caseInsensitive enables case-insensitive matching of columns when scanning rows.
+	caseInsensitive bool
+
+	lookups       sync.Map // reflect.Type -> cachedFieldLookup
+	foldedLookups sync.Map // reflect.Type -> cachedFieldLookup
+	scanPlans     sync.Map // scanPlanKey -> *scanPlan
+}
+
+type cachedFieldLookup struct {
+	lookup fieldLookupMap
+	err    error
+}
+
+type scanPlanKey struct {
+	t       reflect.Type
+	columns string
+}
+
+func newNaming(mapper NameMapper, caseInsensitive bool) *naming {
+	if mapper == nil {
+		mapper = IdentityNames
+	}
+
+	return &naming{
+		mapper:          mapper,
+		caseInsensitive: caseInsensitive,
+	}
+}
+
+// lookup returns the lookup map of a struct or pointer to a struct type.
+// The lookup map is cached per type and must not be modified.
+func (n *naming) lookup(t reflect.Type) (fieldLookupMap, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if cached, ok := n.lookups.Load(t); ok {
+		cached := cached.(cachedFieldLookup)
+		return cached.lookup, cached.err
+	}
+
+	lookup := make(fieldLookupMap)
+	err := analyzeStructFields(lookup, structPath{}, t, n.mapper)
+
+	n.lookups.Store(t, cachedFieldLookup{lookup: lookup, err: err})
+	return lookup, err
+}
+
+// columnLookup returns the lookup map of t and the columns to match against it. If columns are
+// matched case-insensitively, both the lookup map and the columns are lower-cased.
+func (n *naming) columnLookup(t reflect.Type, columns []string) (fieldLookupMap, []string, error) {
+	lookup, err := n.lookup(t)
+	if err != nil || !n.caseInsensitive {
+		return lookup, columns, err
+	}
+
+	folded, err := n.foldedLookup(t, lookup)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foldedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		foldedColumns[i] = strings.ToLower(column)
+	}
+
+	return folded, foldedColumns, nil
+}
+
+func (n *naming) foldedLookup(t reflect.Type, lookup fieldLookupMap) (fieldLookupMap, error) {
+	if cached, ok := n.foldedLookups.Load(t); ok {
+		cached := cached.(cachedFieldLookup)
+		return cached.lookup, cached.err
+	}
+
+	folded := make(fieldLookupMap, len(lookup))
+
+	var err error
+	for name, field := range lookup {
+		if field.aliasOf != "" {
+			field.aliasOf = strings.ToLower(field.aliasOf)
+		}
+
+		if err = folded.add(strings.ToLower(name), field); err != nil {
+			break
+		}
+	}
+
+	n.foldedLookups.Store(t, cachedFieldLookup{lookup: folded, err: err})
+	return folded, err
+}
+
+// scanPlan returns the cached scan plan of columns into the struct type t (see buildScanPlan).
+func (n *naming) scanPlan(t reflect.Type, lookup fieldLookupMap, columns []string) *scanPlan {
+	key := scanPlanKey{t: t, columns: strings.Join(columns, "\x00")}
+
+	if cached, ok := n.scanPlans.Load(key); ok {
+		return cached.(*scanPlan)
+	}
+
+	cached, _ := n.scanPlans.LoadOrStore(key, buildScanPlan(t, lookup, columns))
+	return cached.(*scanPlan)
+}
@@ -0,0 +1,64 @@
+package noorm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameMappers(t *testing.T) {
+	for name, expected := range map[string][3]string{
+		"ID":         {"ID", "id", "id"},
+		"UserID":     {"UserID", "userid", "user_id"},
+		"CreatedAt":  {"CreatedAt", "createdat", "created_at"},
+		"HTTPServer": {"HTTPServer", "httpserver", "http_server"},
+		"Field2":     {"Field2", "field2", "field2"},
+		"Field2Name": {"Field2Name", "field2name", "field2_name"},
+		"name":       {"name", "name", "name"},
+	} {
+		field := reflect.StructField{Name: name}
+
+		assert.Equal(t, expected[0], IdentityNames(field), "name=%q", name)
+		assert.Equal(t, expected[1], LowerCaseNames(field), "name=%q", name)
+		assert.Equal(t, expected[2], SnakeCaseNames(field), "name=%q", name)
+	}
+}
+
+func TestNamingLookup(t *testing.T) {
+	type TestStruct struct {
+		UserID    int
+		Tagged    string `db:"TaggedName"`
+		CreatedAt string `db:",readonly"`
+	}
+
+	target := reflect.TypeOf(TestStruct{})
+	naming := newNaming(SnakeCaseNames, false)
+
+	lookup, err := naming.lookup(target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TaggedName", "created_at", "user_id"}, lookup.columns(nil))
+
+	// columns are matched exactly
+	lookup, columns, err := naming.columnLookup(target, []string{"USER_ID"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USER_ID"}, columns)
+	assert.NotContains(t, lookup, "USER_ID")
+
+	naming = newNaming(SnakeCaseNames, true)
+
+	lookup, columns, err = naming.columnLookup(target, []string{"USER_ID", "TAGGEDNAME"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_id", "taggedname"}, columns)
+	assert.Contains(t, lookup, "user_id")
+	assert.Contains(t, lookup, "taggedname")
+
+	type AmbiguousStruct struct {
+		Name  string `db:"name"`
+		Other string `db:"NAME"`
+	}
+
+	_, _, err = naming.columnLookup(reflect.TypeOf(AmbiguousStruct{}), nil)
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+}
@@ -1,15 +1,22 @@
 package noorm
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"fmt"
+	"reflect"
 )
 
 // Struct must be a struct.
 type Struct any
 
 // Iterator is a typed wrapper for *sql.Rows, which scans rows into T.
-type Iterator[T Struct] interface {
+// If T is a struct or a pointer to a struct, the columns are mapped to its fields and a new struct
+// is allocated for every row of the latter. If T is a Record or map[string]any, rows
+// of any shape are scanned dynamically. Otherwise T must be a scalar type (eg. int, string,
+// time.Time or types implementing sql.Scanner) and the rows must have exactly one column.
+type Iterator[T any] interface {
 	// Next proceeds with the next row.
 	// Next must be called before the first row can be scanned.
 	Next() bool
@@ -22,50 +29,289 @@ type Iterator[T Struct] interface {
 }
 
 // ArgumentSource captures the provided named or positional arguments as a single type.
-// See `Named`, `Positional` and `None` for implementations.
+// See `Named`, `Positional`, `Map`, `Merge` and `None` for implementations.
 type ArgumentSource interface {
 	arg(name string) (any, error)
 }
 
 type QuerySource interface {
-	rebind(Dialect) (query string, params []any, err error)
+	rebind(dialect Dialect, naming *naming) (query string, params []any, err error)
 }
 
 // Exec executes a query without returning rows.
 // Exec expects a Querier to be present in the context (see WithDatabase).
 func Exec(ctx context.Context, query QuerySource) (sql.Result, error) {
-	querier, dialect, err := QuerierFrom(ctx)
+	if batch, ok := query.(batchQuerySource); ok {
+		return execBatch(ctx, batch)
+	}
+
+	querier, db, err := querierFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rebound, params, err := query.rebind(db.dialect, db.getNaming())
+	if err != nil {
+		return nil, err
+	}
+
+	return db.execStatement(ctx, querier, query, rebound, params)
+}
+
+// execBatch executes all queries of a batch within a single transaction, which is nested into the
+// transaction of the context if present. If the dialect does not support savepoints, the queries
+// are executed directly within the transaction of the context.
+func execBatch(ctx context.Context, batch batchQuerySource) (sql.Result, error) {
+	_, db, err := querierFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := batch.rebindBatch(db.dialect, db.getNaming())
 	if err != nil {
 		return nil, err
 	}
 
-	rebound, params, err := query.rebind(dialect)
+	nestable := !inTransaction(ctx) || CapabilitiesOf(db.dialect).Savepoints != SavepointsUnsupported
+
+	if len(queries) > 1 && nestable {
+		var tx Tx
+
+		ctx, tx, err = Begin(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		defer tx.Rollback()
+
+		result, err := execQueries(ctx, batch, queries)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, tx.Commit()
+	}
+
+	return execQueries(ctx, batch, queries)
+}
+
+func execQueries(ctx context.Context, source QuerySource,
+	queries []reboundQuery) (sql.Result, error) {
+	querier, db, err := querierFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return querier.ExecContext(ctx, rebound, params...)
+	var result batchResult
+
+	for _, query := range queries {
+		queryResult, err := db.execStatement(ctx, querier, source, query.query, query.params)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := result.add(queryResult); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+// batchResult combines the results of multiple queries.
+// RowsAffected is the sum of all queries and LastInsertId is reported by the last query.
+type batchResult struct {
+	rowsAffected int64
+	lastResult   sql.Result
+}
+
+func (r *batchResult) add(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	r.rowsAffected += rowsAffected
+	r.lastResult = result
+
+	return nil
+}
+
+func (r *batchResult) LastInsertId() (int64, error) {
+	if r.lastResult == nil {
+		return 0, nil
+	}
+
+	return r.lastResult.LastInsertId()
+}
+
+func (r *batchResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// ExecAffected executes a query without returning rows and reports the number of affected rows.
+// ExecAffected expects a Querier to be present in the context (see WithDatabase).
+func ExecAffected(ctx context.Context, query QuerySource) (int64, error) {
+	result, err := Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// InsertReturning executes an insert query and scans the inserted row back into the model, so
+// that values generated by the database (eg. ids, defaults or timestamps) are available.
+// The model of the insert must be a pointer to a struct.
+// If the Dialect supports `returning` clauses (see Capabilities), the row is returned by the
+// insert query itself or into out parameters pointing to the fields of the model. Otherwise the
+// row is selected by its primary key afterwards, which is taken from the last insert id, when the
+// primary key is a single `auto` field.
+// InsertReturning expects a Querier to be present in the context (see WithDatabase).
+func InsertReturning(ctx context.Context, insert Insert) error {
+	target := reflect.ValueOf(insert.Model)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: cannot scan into %T, expected a pointer to a struct",
+			ErrInvalidTargetType, insert.Model)
+	}
+
+	querier, db, err := querierFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	naming := db.getNaming()
+	capabilities := CapabilitiesOf(db.dialect)
+
+	if capabilities.Returning {
+		insert.Returning = true
+
+		query, params, err := insert.rebind(db.dialect, naming)
+		if err != nil {
+			return err
+		}
+
+		rows, err := db.queryStatement(ctx, querier, insert, query, params)
+		if err != nil {
+			return err
+		}
+
+		return scanFirst(rows, target, strictModeFrom(ctx), naming)
+	}
+
+	insert.Returning = false
+
+	if capabilities.ReturningInto {
+		return insertReturningInto(ctx, insert)
+	}
+
+	return insertAndSelect(ctx, insert, target)
+}
+
+// insertReturningInto returns the inserted row into out parameters pointing to the fields of the
+// model.
+func insertReturningInto(ctx context.Context, insert Insert) error {
+	querier, db, err := querierFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	naming := db.getNaming()
+
+	args, err := namedModel(insert.Model, naming)
+	if err != nil {
+		return err
+	}
+
+	query, params, err := insert.rebindReturningInto(db.dialect, naming, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.execStatement(ctx, querier, insert, query, params)
+	return err
+}
+
+// insertAndSelect emulates a returning clause by selecting the inserted row by its primary key.
+func insertAndSelect(ctx context.Context, insert Insert, target reflect.Value) error {
+	querier, db, err := querierFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	naming := db.getNaming()
+
+	args, err := namedModel(insert.Model, naming)
+	if err != nil {
+		return err
+	}
+
+	key := args.primaryKey()
+	if len(key) == 0 {
+		return fmt.Errorf("%w: selecting the inserted row of %q requires a primary key",
+			ErrInvalidArg, insert.Tablename)
+	}
+
+	result, err := Exec(ctx, insert)
+	if err != nil {
+		return err
+	}
+
+	if field := args.lookupMap[key[0]]; len(key) == 1 && field.auto {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := setInt64(args.field(field), id); err != nil {
+			return err
+		}
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString("select * from ")
+	buffer.WriteString(db.dialect.QuoteIdentifier(insert.Tablename))
+	buffer.WriteString(" where ")
+	writeAssignments(&buffer, db.dialect, key, " and ")
+	buffer.WriteString(terminatorOf(db.dialect))
+
+	query, params, err := rebindQuery(db.dialect, naming, buffer.String(), args)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.queryStatement(ctx, querier, insert, query, params)
+	if err != nil {
+		return err
+	}
+
+	return scanFirst(rows, target, strictModeFrom(ctx), naming)
 }
 
 // Iterate executes a query and returns an iterator of the rows.
+// If T is a struct, the mapping of columns to fields is checked according to the strict mode of
+// the context or database (see WithStrict and Database.SetStrict).
 // Iterate expects a Querier to be present in the context (see WithDatabase).
-func Iterate[T Struct](ctx context.Context, query QuerySource) (Iterator[T], error) {
-	querier, dialect, err := QuerierFrom(ctx)
+func Iterate[T any](ctx context.Context, query QuerySource) (Iterator[T], error) {
+	querier, db, err := querierFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	rebound, params, err := query.rebind(dialect)
+	naming := db.getNaming()
+
+	rebound, params, err := query.rebind(db.dialect, naming)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := querier.QueryContext(ctx, rebound, params...)
+	rows, err := db.queryStatement(ctx, querier, query, rebound, params)
 	if err != nil {
 		return nil, err
 	}
 
-	iter, err := newIterator[T](rows)
+	iter, err := newIterator[T](rows, strictModeFrom(ctx), naming)
 	if err != nil {
 		rows.Close() // close rows early, because we do not return a reference to it
 		return nil, err
@@ -76,7 +322,7 @@ func Iterate[T Struct](ctx context.Context, query QuerySource) (Iterator[T], err
 
 // Query executes a query and returns a slice of T.
 // Query expects a Querier to be present in the context (see WithDatabase).
-func Query[T Struct](ctx context.Context, query QuerySource) ([]T, error) {
+func Query[T any](ctx context.Context, query QuerySource) ([]T, error) {
 	iter, err := Iterate[T](ctx, query)
 	if err != nil {
 		return nil, err
@@ -106,7 +352,7 @@ func Query[T Struct](ctx context.Context, query QuerySource) ([]T, error) {
 // If the query yields no rows, sql.ErrNoRows is returned.
 // If the query yields more than one row, the remaining rows are discarded.
 // QueryFirst expects a Querier to be present in the context (see WithDatabase).
-func QueryFirst[T Struct](ctx context.Context, query QuerySource) (*T, error) {
+func QueryFirst[T any](ctx context.Context, query QuerySource) (*T, error) {
 	iter, err := Iterate[T](ctx, query)
 	if err != nil {
 		return nil, err
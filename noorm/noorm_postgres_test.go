@@ -4,7 +4,10 @@ package noorm
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"testing"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/suite"
@@ -140,3 +143,412 @@ func (s *PostgresTestSuite) TestIterate() {
 
 	s.False(iterator.Next())
 }
+
+func (s *PostgresTestSuite) TestUpdate() {
+	affected, err := ExecAffected(s.ctx, Update{
+		Tablename: "users",
+		Model:     testStructUser{ID: 2, Name: "Qux"},
+		Key:       []string{"id"},
+	})
+
+	s.Require().NoError(err)
+	s.Equal(int64(1), affected)
+
+	user, err := QueryFirst[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" where "id" = @0 ;`,
+		Args:  Positional(2),
+	})
+
+	s.Require().NoError(err)
+	s.Equal(&testStructUser{ID: 2, Name: "Qux"}, user)
+}
+
+func (s *PostgresTestSuite) TestUpsert() {
+	for _, user := range []testStructUser{
+		{ID: 2, Name: "Qux"},
+		{ID: 4, Name: "Quux"},
+	} {
+		_, err := Exec(s.ctx, Upsert{
+			Tablename: "users",
+			Model:     user,
+			Conflict:  []string{"id"},
+		})
+
+		s.Require().NoError(err)
+	}
+
+	users, err := Query[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" order by "id" asc ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]testStructUser{
+		{ID: 1, Name: "Foo"},
+		{ID: 2, Name: "Qux"},
+		{ID: 3, Name: "Baz"},
+		{ID: 4, Name: "Quux"},
+	}, users)
+}
+
+func (s *PostgresTestSuite) TestUpsert_Auto() {
+	type user struct {
+		ID   int    `db:"id,pk,auto"`
+		Name string `db:"name"`
+	}
+
+	// the primary key is inserted to detect the conflict, although it is tagged auto
+	_, err := Exec(s.ctx, Upsert{
+		Tablename: "users",
+		Model:     user{ID: 1, Name: "Qux"},
+	})
+
+	s.Require().NoError(err)
+
+	users, err := Query[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" order by "id" asc ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]testStructUser{
+		{ID: 1, Name: "Qux"},
+		{ID: 2, Name: "Bar"},
+		{ID: 3, Name: "Baz"},
+	}, users)
+}
+
+func (s *PostgresTestSuite) TestInsert_Auto() {
+	type user struct {
+		ID   int    `db:"id,pk,auto"`
+		Name string `db:"name"`
+	}
+
+	_, err := Exec(s.ctx, Insert{
+		Tablename: "users",
+		Model:     user{ID: 1, Name: "Qux"},
+	})
+
+	s.Require().NoError(err)
+
+	users, err := Query[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" where "id" > @0 ;`,
+		Args:  Positional(3),
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]testStructUser{{ID: 4, Name: "Qux"}}, users)
+}
+
+func (s *PostgresTestSuite) TestInsertBatch() {
+	type user struct {
+		ID   int    `db:"id,pk,auto"`
+		Name string `db:"name"`
+	}
+
+	// exceeds the parameter limit of a single query
+	users := make([]user, 70000)
+	for i := range users {
+		users[i].Name = fmt.Sprintf("User %d", i)
+	}
+
+	affected, err := ExecAffected(s.ctx, InsertBatch{
+		Tablename: "users",
+		Models:    users,
+	})
+
+	s.Require().NoError(err)
+	s.Equal(int64(len(users)), affected)
+
+	count, err := QueryFirst[struct {
+		Count int `db:"count"`
+	}](s.ctx, SQL{
+		Query: `select count(*) as "count" from "users" ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal(len(users)+3, count.Count)
+}
+
+func (s *PostgresTestSuite) TestInsertBatch_OmitEmpty() {
+	_, err := s.db.Exec(`
+		drop table if exists "tasks" ;
+		create table "tasks" (
+			"id"     serial primary key ,
+			"name"   varchar ( 64 ) not null ,
+			"status" varchar ( 64 ) not null default 'new'
+		) ;
+	`)
+	s.Require().NoError(err)
+
+	type task struct {
+		ID     int    `db:"id,pk,auto"`
+		Name   string `db:"name"`
+		Status string `db:"status,omitempty"`
+	}
+
+	// zero values of omitempty fields are skipped, so that the default of the column applies
+	_, err = Exec(s.ctx, InsertBatch{
+		Tablename: "tasks",
+		Models:    []task{{Name: "a"}, {Name: "b", Status: "done"}, {Name: "c"}},
+	})
+	s.Require().NoError(err)
+
+	tasks, err := Query[task](s.ctx, SQL{
+		Query: `select * from "tasks" order by "id" asc ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]task{
+		{ID: 1, Name: "a", Status: "new"},
+		{ID: 2, Name: "c", Status: "new"},
+		{ID: 3, Name: "b", Status: "done"},
+	}, tasks)
+}
+
+func (s *PostgresTestSuite) TestInsertReturning() {
+	type user struct {
+		ID   int    `db:"id,pk,auto"`
+		Name string `db:"name"`
+	}
+
+	model := user{Name: "Qux"}
+
+	err := InsertReturning(s.ctx, Insert{
+		Tablename: "users",
+		Model:     &model,
+	})
+
+	s.Require().NoError(err)
+	s.Equal(user{ID: 4, Name: "Qux"}, model)
+}
+
+func (s *PostgresTestSuite) TestQuery_EmptyList() {
+	users, err := Query[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" where "name" in (@0) ;`,
+		Args:  Positional(List[string]{}),
+	})
+
+	s.Require().NoError(err)
+	s.Empty(users)
+}
+
+func (s *PostgresTestSuite) TestQuery_EmptyListNotIn() {
+	// `not in (null)` would never be true, so the empty list is rejected
+	users, err := Query[testStructUser](s.ctx, SQL{
+		Query: `select * from "users" where "name" not in (@0) ;`,
+		Args:  Positional(List[string]{}),
+	})
+
+	s.ErrorIs(err, ErrInvalidArg)
+	s.Empty(users)
+}
+
+func (s *PostgresTestSuite) TestQuery_Scalar() {
+	count, err := QueryFirst[int](s.ctx, SQL{
+		Query: `select count(*) from "users" ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal(3, *count)
+
+	names, err := Query[string](s.ctx, SQL{
+		Query: `select "name" from "users" order by "id" asc ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]string{"Foo", "Bar", "Baz"}, names)
+
+	name, err := QueryFirst[sql.NullString](s.ctx, SQL{
+		Query: `select null ;`,
+	})
+
+	s.Require().NoError(err)
+	s.False(name.Valid)
+
+	_, err = Query[string](s.ctx, SQL{
+		Query: `select * from "users" ;`,
+	})
+
+	s.ErrorIs(err, ErrInvalidTargetType)
+}
+
+func (s *PostgresTestSuite) TestQuery_NestedPointer() {
+	type Address struct {
+		Street string `db:"street"`
+	}
+
+	type UserWithAddress struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+		*Address
+	}
+
+	users, err := Query[UserWithAddress](s.ctx, SQL{
+		Query: `
+			select "id", "name", case when "id" = 1 then 'Main Street' end as "street"
+			from "users"
+			order by "id" asc ;
+		`,
+	})
+
+	s.Require().NoError(err)
+	s.Require().Len(users, 3)
+	s.Equal(&Address{Street: "Main Street"}, users[0].Address)
+	s.Nil(users[1].Address)
+	s.Nil(users[2].Address)
+}
+
+func (s *PostgresTestSuite) TestQuery_Prefixed() {
+	type UserPair struct {
+		User testStructUser  `db:"user,prefix=user_"`
+		Next *testStructUser `db:"next,prefix=next_"`
+	}
+
+	pairs, err := Query[UserPair](s.ctx, SQL{
+		Query: `
+			select
+				u."id" as "user_id", u."name" as "user_name",
+				n."id" as "next.id", n."name" as "next.name"
+			from "users" u
+			left join "users" n on n."id" = u."id" + 1
+			where u."id" >= @user.id
+			order by u."id" asc ;
+		`,
+		Args: Named(UserPair{User: testStructUser{ID: 2}}),
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]UserPair{
+		{User: testStructUser{ID: 2, Name: "Bar"}, Next: &testStructUser{ID: 3, Name: "Baz"}},
+		{User: testStructUser{ID: 3, Name: "Baz"}},
+	}, pairs)
+}
+
+func (s *PostgresTestSuite) TestQueryAggregate() {
+	type UserWithSuccessors struct {
+		ID         int              `db:"id,pk"`
+		Name       string           `db:"name"`
+		Successors []testStructUser `db:"successors,prefix=successor_"`
+	}
+
+	users, err := QueryAggregate[UserWithSuccessors](s.ctx, SQL{
+		Query: `
+			select
+				u."id", u."name",
+				n."id" as "successor_id", n."name" as "successor_name"
+			from "users" u
+			left join "users" n on n."id" > u."id"
+			order by u."id" asc, n."id" asc ;
+		`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]UserWithSuccessors{
+		{ID: 1, Name: "Foo", Successors: []testStructUser{{ID: 2, Name: "Bar"}, {ID: 3, Name: "Baz"}}},
+		{ID: 2, Name: "Bar", Successors: []testStructUser{{ID: 3, Name: "Baz"}}},
+		{ID: 3, Name: "Baz"},
+	}, users)
+}
+
+func (s *PostgresTestSuite) TestQuery_Null() {
+	type User struct {
+		ID   int          `db:"id,pk,auto"`
+		Name Null[string] `db:"name"`
+	}
+
+	_, err := Exec(s.ctx, Insert{Tablename: "users", Model: User{Name: NullOf("Qux")}})
+	s.Require().NoError(err)
+
+	type Result struct {
+		Name    Null[string]    `db:"name"`
+		Created Null[time.Time] `db:"created"`
+	}
+
+	results, err := Query[Result](s.ctx, SQL{
+		Query: `
+			select
+				case when "id" = 4 then null else "name" end as "name",
+				case when "id" = 1 then timestamp '2022-01-02 03:04:05' end as "created"
+			from "users"
+			where "id" in (@ids)
+			order by "id" asc ;
+		`,
+		Args: Map(map[string]any{"ids": List[int]{1, 2, 4}}),
+	})
+
+	s.Require().NoError(err)
+	s.Require().Len(results, 3)
+
+	s.Equal(NullOf("Foo"), results[0].Name)
+	s.True(results[0].Created.Valid)
+	s.True(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC).Equal(results[0].Created.V))
+
+	s.Equal(NullOf("Bar"), results[1].Name)
+	s.False(results[1].Created.Valid)
+
+	s.Equal(Null[string]{}, results[2].Name)
+}
+
+func (s *PostgresTestSuite) TestQuery_JSON() {
+	_, err := s.db.Exec(`
+		drop table if exists "documents" ;
+		create table "documents" ( "id" serial primary key , "doc" jsonb not null ) ;
+	`)
+	s.Require().NoError(err)
+
+	type Document struct {
+		ID  int                `db:"id,pk,auto"`
+		Doc JSON[testDocument] `db:"doc"`
+	}
+
+	for _, document := range []Document{
+		{Doc: JSON[testDocument]{V: testDocument{Title: "Foo", Tags: []string{"a"}}}},
+		{Doc: JSON[testDocument]{V: testDocument{Title: "Bar", Tags: []string{"b", "c"}}}},
+	} {
+		_, err := Exec(s.ctx, Insert{Tablename: "documents", Model: document})
+		s.Require().NoError(err)
+	}
+
+	path, err := JSONPath(s.db.dialect, s.db.dialect.QuoteIdentifier("doc"), "tags", "0")
+	s.Require().NoError(err)
+
+	documents, err := Query[Document](s.ctx, SQL{
+		Query: `select * from "documents" where ` + path + ` = @tag ;`,
+		Args:  Map(map[string]any{"tag": "b"}),
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]Document{
+		{ID: 2, Doc: JSON[testDocument]{V: testDocument{Title: "Bar", Tags: []string{"b", "c"}}}},
+	}, documents)
+}
+
+func (s *PostgresTestSuite) TestQuery_Map() {
+	users, err := Query[map[string]any](s.ctx, SQL{
+		Query: `select "id", "name" from "users" order by "id" asc ;`,
+	})
+
+	s.Require().NoError(err)
+	s.Equal([]map[string]any{
+		{"id": int64(1), "name": "Foo"},
+		{"id": int64(2), "name": "Bar"},
+		{"id": int64(3), "name": "Baz"},
+	}, users)
+}
+
+func (s *PostgresTestSuite) TestQuery_Record() {
+	user, err := QueryFirst[Record](s.ctx, SQL{
+		Query: `select "id", "name" from "users" where "id" = @id ;`,
+		Args:  Map(map[string]any{"id": 2}),
+	})
+
+	s.Require().NoError(err)
+	s.Len(user.Columns, 2)
+	s.Equal([]any{int64(2), "Bar"}, user.Values)
+
+	name, ok := user.Get("name")
+	s.True(ok)
+	s.Equal("Bar", name)
+
+	_, ok = user.Get("missing")
+	s.False(ok)
+}
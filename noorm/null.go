@@ -0,0 +1,303 @@
+package noorm
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ sql.Scanner      = &Null[int]{}
+	_ driver.Valuer    = Null[int]{}
+	_ json.Marshaler   = Null[int]{}
+	_ json.Unmarshaler = &Null[int]{}
+)
+
+// Null is a nullable value of any type, which can be scanned from and bound as query parameter.
+// Null is encoded as `null` in json, if it is not valid.
+type Null[T any] struct {
+	V     T
+	Valid bool // Valid is true, if V is not null
+}
+
+// NullOf returns a valid Null with the value v.
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Scan implements sql.Scanner. If T implements sql.Scanner itself, it is used for non-null values.
+// Otherwise the value is converted similar to database/sql. Strings are parsed into time.Time
+// using the formats of SQLite, which stores timestamps as text.
+func (n *Null[T]) Scan(src any) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+
+	var v T
+
+	if scanner, ok := any(&v).(sql.Scanner); ok {
+		if err := scanner.Scan(src); err != nil {
+			return err
+		}
+	} else if err := convertNullValue(src, reflect.ValueOf(&v).Elem()); err != nil {
+		return err
+	}
+
+	*n = NullOf(v)
+	return nil
+}
+
+// Value implements driver.Valuer. If T implements driver.Valuer itself, it is used for valid values.
+func (n Null[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	if valuer, ok := any(n.V).(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Null[T]{}
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*n = NullOf(v)
+	return nil
+}
+
+// sqliteTimestampFormats are the formats used by SQLite to store timestamps as text.
+var sqliteTimestampFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// convertNullValue assigns a non-null driver value to dest.
+func convertNullValue(src any, dest reflect.Value) error {
+	if b, ok := src.([]byte); ok {
+		if dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.Uint8 {
+			// the driver may reuse the buffer, so the bytes must be copied
+			dest.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+
+		src = string(b)
+	}
+
+	if dest.Type() == timeType {
+		t, err := convertTime(src)
+		if err != nil {
+			return err
+		}
+
+		dest.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if s, ok := src.(string); ok {
+		return convertString(s, dest)
+	}
+
+	value := reflect.ValueOf(src)
+
+	switch {
+	case dest.Kind() == reflect.String:
+		// avoid converting integers to runes
+		return convertString(fmt.Sprint(src), dest)
+
+	case dest.Kind() == reflect.Bool && value.CanInt():
+		// eg. sqlite stores booleans as integers
+		dest.SetBool(value.Int() != 0)
+		return nil
+
+	case dest.Kind() == reflect.Bool && value.Kind() == reflect.Bool:
+		dest.SetBool(value.Bool())
+		return nil
+
+	case isNumeric(dest.Kind()) && isNumeric(value.Kind()):
+		return convertNumber(value, dest)
+	}
+
+	return fmt.Errorf("%w: cannot scan %T into %q", ErrInvalidTargetType, src, dest.Type())
+}
+
+// convertNumber assigns a number to dest. Like sql.Rows.Scan, it rejects values, which overflow
+// dest, and floats with a fraction for integer destinations.
+func convertNumber(value, dest reflect.Value) error {
+	var lossless bool
+
+	switch kind := dest.Kind(); {
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		var i int64
+		if i, lossless = numberToInt64(value); lossless && !dest.OverflowInt(i) {
+			dest.SetInt(i)
+			return nil
+		}
+
+	case kind >= reflect.Uint && kind <= reflect.Uint64:
+		var u uint64
+		if u, lossless = numberToUint64(value); lossless && !dest.OverflowUint(u) {
+			dest.SetUint(u)
+			return nil
+		}
+
+	default:
+		f := numberToFloat64(value)
+		if !dest.OverflowFloat(f) {
+			dest.SetFloat(f)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: cannot scan %v into %q without loss", ErrInvalidTargetType, value,
+		dest.Type())
+}
+
+func numberToInt64(value reflect.Value) (int64, bool) {
+	switch {
+	case value.CanInt():
+		return value.Int(), true
+
+	case value.CanUint():
+		u := value.Uint()
+		return int64(u), u <= math.MaxInt64
+
+	default:
+		f := value.Float()
+		// 2^63 is the first float64 exceeding the range of int64
+		return int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	}
+}
+
+func numberToUint64(value reflect.Value) (uint64, bool) {
+	switch {
+	case value.CanInt():
+		i := value.Int()
+		return uint64(i), i >= 0
+
+	case value.CanUint():
+		return value.Uint(), true
+
+	default:
+		f := value.Float()
+		// 2^64 is the first float64 exceeding the range of uint64
+		return uint64(f), f == math.Trunc(f) && f >= 0 && f < math.MaxUint64
+	}
+}
+
+func numberToFloat64(value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+
+	case value.CanUint():
+		return float64(value.Uint())
+
+	default:
+		return value.Float()
+	}
+}
+
+// convertString parses a string into dest.
+func convertString(s string, dest reflect.Value) error {
+	var err error
+
+	switch kind := dest.Kind(); {
+	case kind == reflect.String:
+		dest.SetString(s)
+
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, dest.Type().Bits()); err == nil {
+			dest.SetInt(i)
+		}
+
+	case kind >= reflect.Uint && kind <= reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, dest.Type().Bits()); err == nil {
+			dest.SetUint(u)
+		}
+
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, dest.Type().Bits()); err == nil {
+			dest.SetFloat(f)
+		}
+
+	case kind == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			dest.SetBool(b)
+		}
+
+	default:
+		return fmt.Errorf("%w: cannot scan string into %q", ErrInvalidTargetType, dest.Type())
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: cannot scan %q into %q: %v", ErrInvalidTargetType, s, dest.Type(), err)
+	}
+
+	return nil
+}
+
+// convertTime converts a driver value into time.Time. Strings are parsed using the formats of
+// SQLite and integers are interpreted as unix timestamps.
+func convertTime(src any) (time.Time, error) {
+	switch src := src.(type) {
+	case time.Time:
+		return src, nil
+
+	case int64:
+		return time.Unix(src, 0).UTC(), nil
+
+	case string:
+		s := strings.TrimSuffix(src, "Z")
+
+		for _, format := range sqliteTimestampFormats {
+			if t, err := time.ParseInLocation(format, s, time.UTC); err == nil {
+				return t, nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("%w: cannot parse %q as time", ErrInvalidTargetType, src)
+	}
+
+	return time.Time{}, fmt.Errorf("%w: cannot scan %T into %q", ErrInvalidTargetType, src, timeType)
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
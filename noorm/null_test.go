@@ -0,0 +1,127 @@
+package noorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullScan(t *testing.T) {
+	var (
+		i  Null[int]
+		u  Null[uint8]
+		f  Null[float32]
+		b  Null[bool]
+		s  Null[string]
+		bs Null[[]byte]
+		ts Null[time.Time]
+	)
+
+	date := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, tc := range []struct {
+		target   any
+		src      any
+		expected any
+	}{
+		{target: &i, src: nil, expected: Null[int]{}},
+		{target: &i, src: int64(42), expected: NullOf(42)},
+		{target: &i, src: []byte("42"), expected: NullOf(42)},
+		{target: &u, src: "255", expected: NullOf[uint8](255)},
+		{target: &f, src: float64(1.5), expected: NullOf[float32](1.5)},
+		{target: &f, src: int64(2), expected: NullOf[float32](2)},
+		{target: &b, src: true, expected: NullOf(true)},
+		{target: &b, src: int64(1), expected: NullOf(true)},
+		{target: &b, src: []byte("0"), expected: NullOf(false)},
+		{target: &s, src: "foo", expected: NullOf("foo")},
+		{target: &s, src: int64(42), expected: NullOf("42")},
+		{target: &bs, src: []byte("foo"), expected: NullOf([]byte("foo"))},
+		{target: &ts, src: date, expected: NullOf(date)},
+		{target: &ts, src: "2022-01-02 03:04:05", expected: NullOf(date)},
+		{target: &ts, src: []byte("2022-01-02T03:04:05Z"), expected: NullOf(date)},
+		{target: &ts, src: "2022-01-02", expected: NullOf(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))},
+		{target: &ts, src: date.Unix(), expected: NullOf(date)},
+	} {
+		require.NoError(t, tc.target.(sql.Scanner).Scan(tc.src), "src=%#v", tc.src)
+		assert.Equal(t, tc.expected, reflect.ValueOf(tc.target).Elem().Interface(), "src=%#v", tc.src)
+	}
+
+	assert.ErrorIs(t, i.Scan("foo"), ErrInvalidTargetType)
+	assert.ErrorIs(t, u.Scan("256"), ErrInvalidTargetType)
+	assert.ErrorIs(t, ts.Scan("yesterday"), ErrInvalidTargetType)
+	assert.ErrorIs(t, i.Scan(date), ErrInvalidTargetType)
+
+	// conversions losing data are rejected like by sql.Rows.Scan
+	var i8 Null[int8]
+	assert.ErrorIs(t, i8.Scan(int64(300)), ErrInvalidTargetType)
+	assert.ErrorIs(t, i8.Scan(float64(-129)), ErrInvalidTargetType)
+	assert.ErrorIs(t, i.Scan(1.5), ErrInvalidTargetType)
+	assert.ErrorIs(t, i.Scan(1e19), ErrInvalidTargetType)
+	assert.ErrorIs(t, u.Scan(int64(-1)), ErrInvalidTargetType)
+	assert.ErrorIs(t, u.Scan(int64(256)), ErrInvalidTargetType)
+	assert.ErrorIs(t, u.Scan(0.5), ErrInvalidTargetType)
+	assert.ErrorIs(t, f.Scan(1e300), ErrInvalidTargetType)
+
+	require.NoError(t, i8.Scan(int64(-128)))
+	assert.Equal(t, NullOf[int8](-128), i8)
+	require.NoError(t, i.Scan(float64(3)))
+	assert.Equal(t, NullOf(3), i)
+}
+
+func TestNullScanBytesCopy(t *testing.T) {
+	src := []byte("foo")
+
+	var n Null[[]byte]
+	require.NoError(t, n.Scan(src))
+
+	src[0] = 'b'
+	assert.Equal(t, []byte("foo"), n.V)
+}
+
+func TestNullValue(t *testing.T) {
+	date := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, tc := range []struct {
+		valuer   driver.Valuer
+		expected driver.Value
+	}{
+		{valuer: Null[int]{}, expected: nil},
+		{valuer: NullOf(42), expected: int64(42)},
+		{valuer: NullOf[uint8](42), expected: int64(42)},
+		{valuer: NullOf("foo"), expected: "foo"},
+		{valuer: NullOf(date), expected: date},
+		{valuer: NullOf(testValuer("foo")), expected: "valuer:foo"},
+		{valuer: Null[testValuer]{}, expected: nil},
+	} {
+		value, err := tc.valuer.Value()
+		require.NoError(t, err, "valuer=%#v", tc.valuer)
+		assert.Equal(t, tc.expected, value, "valuer=%#v", tc.valuer)
+	}
+}
+
+func TestNullJSON(t *testing.T) {
+	type TestStruct struct {
+		A Null[int]    `json:"a"`
+		B Null[string] `json:"b"`
+	}
+
+	data, err := json.Marshal(TestStruct{A: NullOf(42)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a": 42, "b": null}`, string(data))
+
+	var s TestStruct
+	require.NoError(t, json.Unmarshal([]byte(`{"a": null, "b": "foo"}`), &s))
+	assert.Equal(t, TestStruct{B: NullOf("foo")}, s)
+}
+
+type testValuer string
+
+func (v testValuer) Value() (driver.Value, error) {
+	return "valuer:" + string(v), nil
+}
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -32,6 +35,21 @@ var (
 type Database struct {
 	*sql.DB
 	dialect Dialect
+	strict  atomic.Uint32 // StrictMode
+	naming  atomic.Pointer[naming]
+
+	interceptors atomic.Pointer[[]Interceptor]
+
+	// err is set, if the dialect could not be detected.
+	err error
+}
+
+// Tx is a transaction or a savepoint within a transaction started by Begin.
+type Tx interface {
+	// Commit commits the transaction or releases the savepoint.
+	Commit() error
+	// Rollback aborts the transaction or rolls back to the savepoint.
+	Rollback() error
 }
 
 type transaction struct {
@@ -39,56 +57,276 @@ type transaction struct {
 
 	// save pointer to db to avoid traversing the context twice
 	db *Database
+
+	// parent is the enclosing transaction, if the transaction is nested.
+	parent *transaction
+	// savepoint is the name of the savepoint, if the transaction is nested.
+	savepoint string
+	// depth is the number of enclosing transactions.
+	depth int
+	// done is set, once a savepoint has been released or rolled back.
+	done bool
+
+	hooks transactionHooks
+}
+
+func (t *transaction) Commit() error {
+	if t.savepoint == "" {
+		err := t.Tx.Commit()
+		if !errors.Is(err, sql.ErrTxDone) {
+			t.hooks.finish(err == nil)
+		}
+
+		return err
+	}
+
+	_, release, _ := t.savepointStatements()
+
+	err := t.execSavepoint(release)
+	if !errors.Is(err, sql.ErrTxDone) {
+		// changes of a released savepoint are only committed with the enclosing transaction
+		t.parent.hooks.adopt(&t.hooks)
+	}
+
+	return err
+}
+
+func (t *transaction) Rollback() error {
+	var err error
+
+	if t.savepoint == "" {
+		err = t.Tx.Rollback()
+	} else {
+		_, _, rollback := t.savepointStatements()
+		err = t.execSavepoint(rollback)
+	}
+
+	if !errors.Is(err, sql.ErrTxDone) {
+		t.hooks.finish(false)
+	}
+
+	return err
+}
+
+// savepointStatements returns the statements to create, release and roll back to the savepoint
+// in the syntax of the dialect (see Capabilities).
+func (t *transaction) savepointStatements() (create, release, rollback string) {
+	syntax := CapabilitiesOf(t.db.dialect).Savepoints
+	return syntax.savepointStatements(t.db.dialect.QuoteIdentifier(t.savepoint))
+}
+
+// execSavepoint executes a statement, which ends the savepoint. An empty statement ends the
+// savepoint without executing anything.
+func (t *transaction) execSavepoint(statement string) error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	t.done = true
+
+	if statement == "" {
+		return nil
+	}
+
+	_, err := t.Tx.Exec(statement)
+	return err
+}
+
+// transactionHooks are the callbacks registered by AfterCommit and AfterRollback.
+type transactionHooks struct {
+	mutex         sync.Mutex
+	afterCommit   []func()
+	afterRollback []func()
+}
+
+func (h *transactionHooks) register(afterCommit, afterRollback func()) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if afterCommit != nil {
+		h.afterCommit = append(h.afterCommit, afterCommit)
+	}
+
+	if afterRollback != nil {
+		h.afterRollback = append(h.afterRollback, afterRollback)
+	}
+}
+
+func (h *transactionHooks) take() (afterCommit, afterRollback []func()) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	afterCommit, afterRollback = h.afterCommit, h.afterRollback
+	h.afterCommit, h.afterRollback = nil, nil
+
+	return afterCommit, afterRollback
+}
+
+// adopt moves all callbacks of a nested transaction to the end of h.
+func (h *transactionHooks) adopt(nested *transactionHooks) {
+	afterCommit, afterRollback := nested.take()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.afterCommit = append(h.afterCommit, afterCommit...)
+	h.afterRollback = append(h.afterRollback, afterRollback...)
 }
 
+// finish calls either the after-commit or after-rollback callbacks in registration order.
+func (h *transactionHooks) finish(committed bool) {
+	afterCommit, afterRollback := h.take()
+
+	callbacks := afterRollback
+	if committed {
+		callbacks = afterCommit
+	}
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// New wraps a database using the dialect. If the dialect is nil, the dialect registered for the
+// driver of the database is used (see RegisterDialect). If there is none, all queries using the
+// returned database fail with ErrUnknownDialect.
 func New(db *sql.DB, dialect Dialect) *Database {
+	var err error
+
+	if dialect == nil {
+		dialect, err = detectDialect(db)
+	}
+
 	return &Database{
 		DB:      db,
 		dialect: dialect,
+		err:     err,
 	}
 }
 
+// SetNameMapper sets the mapping of struct fields without explicit name in their `db` tag to
+// column names. The default is IdentityNames.
+func (db *Database) SetNameMapper(mapper NameMapper) {
+	db.naming.Store(newNaming(mapper, db.getNaming().caseInsensitive))
+}
+
+// SetCaseInsensitiveColumns enables case-insensitive matching of columns to struct fields when
+// scanning rows, eg. for drivers reporting upper-cased column names.
+func (db *Database) SetCaseInsensitiveColumns(enabled bool) {
+	db.naming.Store(newNaming(db.getNaming().mapper, enabled))
+}
+
+func (db *Database) getNaming() *naming {
+	if naming := db.naming.Load(); naming != nil {
+		return naming
+	}
+
+	return defaultNaming
+}
+
+// Open opens a database using the dialect registered for the driver (see RegisterDialect).
+// If there is none, ErrUnknownDialect is returned. Use New with an explicit dialect instead.
 func Open(driverName, dataSourceName string) (*Database, error) {
+	dialect, ok := lookupDialect(driverName)
+	if !ok {
+		return nil, fmt.Errorf("%w: no dialect registered for driver %q (see RegisterDialect)",
+			ErrUnknownDialect, driverName)
+	}
+
 	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	return New(db, guessDialect(driverName)), nil
+	return New(db, dialect), nil
 }
 
 func WithDatabase(ctx context.Context, db *Database) context.Context {
 	return context.WithValue(ctx, ctxDatabaseKey{}, db)
 }
 
-func Begin(ctx context.Context, opts *sql.TxOptions) (context.Context, *sql.Tx, error) {
+// Begin starts a transaction and returns a context carrying it.
+// If the context already carries a transaction, a savepoint is created within it instead, so that
+// Commit and Rollback of the returned Tx only release or roll back to the savepoint. The options
+// are ignored for savepoints. If the dialect does not support savepoints (see Capabilities), nested
+// transactions fail with ErrUnsupported.
+func Begin(ctx context.Context, opts *sql.TxOptions) (context.Context, Tx, error) {
+	if outer, ok := ctx.Value(ctxTransactionKey{}).(*transaction); ok {
+		return beginSavepoint(ctx, outer)
+	}
+
 	db, ok := ctx.Value(ctxDatabaseKey{}).(*Database)
 	if !ok {
 		return ctx, nil, fmt.Errorf("%w: cannot begin transaction", ErrNoDatabaseInContext)
 	}
 
-	tx, err := db.BeginTx(ctx, opts)
+	if db.err != nil {
+		return ctx, nil, db.err
+	}
+
+	sqlTx, err := db.BeginTx(ctx, opts)
 	if err != nil {
 		return ctx, nil, err
 	}
 
-	ctx = context.WithValue(ctx, ctxTransactionKey{}, &transaction{
-		Tx: tx,
+	tx := &transaction{
+		Tx: sqlTx,
 		db: db,
-	})
+	}
+
+	return context.WithValue(ctx, ctxTransactionKey{}, tx), tx, nil
+}
+
+func beginSavepoint(ctx context.Context, outer *transaction) (context.Context, Tx, error) {
+	depth := outer.depth + 1
+	nested := &transaction{
+		Tx:        outer.Tx,
+		db:        outer.db,
+		parent:    outer,
+		savepoint: "noorm_savepoint_" + strconv.Itoa(depth),
+		depth:     depth,
+	}
+
+	create, _, _ := nested.savepointStatements()
+	if create == "" {
+		return ctx, nil, fmt.Errorf("%w: nested transactions", ErrUnsupported)
+	}
 
-	return ctx, tx, nil
+	if _, err := outer.ExecContext(ctx, create); err != nil {
+		return ctx, nil, err
+	}
+
+	return context.WithValue(ctx, ctxTransactionKey{}, nested), nested, nil
+}
+
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(ctxTransactionKey{}).(*transaction)
+	return ok
 }
 
 func QuerierFrom(ctx context.Context) (Querier, Dialect, error) {
+	querier, db, err := querierFrom(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return querier, db.dialect, nil
+}
+
+// querierFrom is like QuerierFrom, but returns the database instead of its dialect.
+func querierFrom(ctx context.Context) (Querier, *Database, error) {
 	tx, ok := ctx.Value(ctxTransactionKey{}).(*transaction)
 	if ok {
-		return tx, tx.db.dialect, nil
+		return tx, tx.db, nil
 	}
 
 	db, ok := ctx.Value(ctxDatabaseKey{}).(*Database)
 	if ok {
-		return db, db.dialect, nil
+		if db.err != nil {
+			return nil, nil, db.err
+		}
+
+		return db, db, nil
 	}
 
 	return nil, nil, ErrNoQuerierInContext
@@ -2,6 +2,7 @@ package noorm
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -70,3 +71,142 @@ func (s *QuerierTestSuite) TestBegin() {
 	s.NotNil(dialect)
 	s.NoError(err)
 }
+
+func (s *QuerierTestSuite) TestBeginNested() {
+	ctx := WithDatabase(s.ctx, s.db)
+
+	_, err := Exec(ctx, SQL{Query: `create table "fruits" ( "name" varchar not null ) ;`})
+	s.Require().NoError(err)
+
+	insert := func(ctx context.Context, name string) {
+		_, err := Exec(ctx, SQL{
+			Query: `insert into "fruits" ( "name" ) values ( @0 ) ;`,
+			Args:  Positional(name),
+		})
+		s.Require().NoError(err)
+	}
+
+	ctx, tx, err := Begin(ctx, nil)
+	s.Require().NoError(err)
+	defer tx.Rollback()
+
+	insert(ctx, "Apple")
+
+	nestedCtx, nestedTx, err := Begin(ctx, nil)
+	s.Require().NoError(err)
+	insert(nestedCtx, "Banana")
+	s.Require().NoError(nestedTx.Rollback())
+	s.ErrorIs(nestedTx.Commit(), sql.ErrTxDone)
+
+	nestedCtx, nestedTx, err = Begin(ctx, nil)
+	s.Require().NoError(err)
+	insert(nestedCtx, "Cherry")
+	s.Require().NoError(nestedTx.Commit())
+	s.ErrorIs(nestedTx.Rollback(), sql.ErrTxDone)
+
+	s.Require().NoError(tx.Commit())
+
+	fruits, err := Query[struct {
+		Name string `db:"name"`
+	}](WithDatabase(s.ctx, s.db), SQL{Query: `select * from "fruits" order by "name" ;`})
+
+	s.Require().NoError(err)
+	s.Len(fruits, 2)
+	s.Equal("Apple", fruits[0].Name)
+	s.Equal("Cherry", fruits[1].Name)
+}
+
+type testSavepointDialect struct {
+	sqliteDialect
+	savepoints    SavepointSyntax
+	maxInsertRows int
+}
+
+func (d testSavepointDialect) Capabilities() Capabilities {
+	capabilities := d.sqliteDialect.Capabilities()
+	capabilities.Savepoints = d.savepoints
+
+	if d.maxInsertRows > 0 {
+		capabilities.MaxInsertRows = d.maxInsertRows
+	}
+
+	return capabilities
+}
+
+func (s *QuerierTestSuite) TestBeginNestedUnsupported() {
+	db := New(s.db.DB, testSavepointDialect{savepoints: SavepointsUnsupported})
+
+	ctx, tx, err := Begin(WithDatabase(s.ctx, db), nil)
+	s.Require().NoError(err)
+	defer tx.Rollback()
+
+	_, nestedTx, err := Begin(ctx, nil)
+	s.ErrorIs(err, ErrUnsupported)
+	s.Nil(nestedTx)
+}
+
+func (s *QuerierTestSuite) TestInsertBatchNestedUnsupported() {
+	db := New(s.db.DB, testSavepointDialect{savepoints: SavepointsUnsupported, maxInsertRows: 2})
+	ctx := WithDatabase(s.ctx, db)
+
+	type fruit struct {
+		Name string `db:"name"`
+	}
+
+	_, err := Exec(ctx, SQL{Query: `create table "fruits" ( "name" varchar not null ) ;`})
+	s.Require().NoError(err)
+
+	ctx, tx, err := Begin(ctx, nil)
+	s.Require().NoError(err)
+	defer tx.Rollback()
+
+	// the batch is split into multiple queries, but must not begin a nested transaction
+	affected, err := ExecAffected(ctx, InsertBatch{
+		Tablename: "fruits",
+		Models:    []fruit{{Name: "Apple"}, {Name: "Banana"}, {Name: "Cherry"}},
+	})
+	s.Require().NoError(err)
+	s.Equal(int64(3), affected)
+	s.Require().NoError(tx.Rollback())
+
+	count, err := QueryFirst[int](WithDatabase(s.ctx, db), SQL{
+		Query: `select count(*) from "fruits" ;`,
+	})
+	s.Require().NoError(err)
+	s.Equal(0, *count)
+}
+
+func (s *QuerierTestSuite) TestBeginNestedWithoutRelease() {
+	db := New(s.db.DB, testSavepointDialect{savepoints: SavepointsWithoutRelease})
+	ctx := WithDatabase(s.ctx, db)
+
+	_, err := Exec(ctx, SQL{Query: `create table "fruits" ( "name" varchar not null ) ;`})
+	s.Require().NoError(err)
+
+	ctx, tx, err := Begin(ctx, nil)
+	s.Require().NoError(err)
+	defer tx.Rollback()
+
+	nestedCtx, nestedTx, err := Begin(ctx, nil)
+	s.Require().NoError(err)
+
+	_, err = Exec(nestedCtx, SQL{Query: `insert into "fruits" ( "name" ) values ( 'Apple' ) ;`})
+	s.Require().NoError(err)
+	s.Require().NoError(nestedTx.Commit())
+	s.ErrorIs(nestedTx.Rollback(), sql.ErrTxDone)
+
+	nestedCtx, nestedTx, err = Begin(ctx, nil)
+	s.Require().NoError(err)
+
+	_, err = Exec(nestedCtx, SQL{Query: `insert into "fruits" ( "name" ) values ( 'Banana' ) ;`})
+	s.Require().NoError(err)
+	s.Require().NoError(nestedTx.Rollback())
+
+	s.Require().NoError(tx.Commit())
+
+	count, err := QueryFirst[int](WithDatabase(s.ctx, db), SQL{
+		Query: `select count(*) from "fruits" ;`,
+	})
+	s.Require().NoError(err)
+	s.Equal(1, *count)
+}
@@ -2,11 +2,28 @@ package noorm
 
 import (
 	"bytes"
+	"database/sql"
+	"fmt"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type requireExplicitFields struct{}
 
+// reboundQuery is a single query ready to be executed.
+type reboundQuery struct {
+	query  string
+	params []any
+}
+
+// batchQuerySource is implemented by query sources, which may require multiple queries.
+type batchQuerySource interface {
+	QuerySource
+	rebindBatch(dialect Dialect, naming *naming) ([]reboundQuery, error)
+}
+
 type SQL struct {
 	requireExplicitFields
 
@@ -14,7 +31,7 @@ type SQL struct {
 	Args  ArgumentSource
 }
 
-func (s SQL) rebind(dialect Dialect) (string, []any, error) {
+func (s SQL) rebind(dialect Dialect, naming *naming) (string, []any, error) {
 	if s.Args == nil {
 		s.Args = None()
 	}
@@ -23,74 +40,494 @@ func (s SQL) rebind(dialect Dialect) (string, []any, error) {
 		return "", nil, err
 	}
 
-	return rebindQuery(dialect, s.Query, s.Args)
+	return rebindQuery(dialect, naming, s.Query, s.Args)
 }
 
+// Insert generates an insert query from the fields of a struct.
+// Fields tagged with the `auto` or `readonly` option are skipped, as well as fields tagged with the
+// `omitempty` option, which have a zero value. If no fields remain, ErrInvalidArg is returned.
 type Insert struct {
 	requireExplicitFields
 
 	Tablename string
 	Model     Struct
+	// Returning returns the inserted row. It requires the Dialect to support returning (see
+	// Capabilities), otherwise ErrUnsupported is returned.
 	Returning bool
 }
 
-func (i Insert) rebind(dialect Dialect) (string, []any, error) {
-	switch args := Named(i.Model).(type) {
-	case invalidArg:
-		return "", nil, args.error
+func (i Insert) rebind(dialect Dialect, naming *naming) (string, []any, error) {
+	args, err := namedModel(i.Model, naming)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, err := i.generateInsertQuery(dialect, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rebindQuery(dialect, naming, query, args)
+}
+
+func (i *Insert) generateInsertQuery(dialect Dialect, args *namedArgs) (string, error) {
+	if i.Returning && !CapabilitiesOf(dialect).Returning {
+		return "", fmt.Errorf("%w: insert into %q returning", ErrUnsupported, i.Tablename)
+	}
+
+	columns, err := i.columns(args)
+	if err != nil {
+		return "", err
+	}
+
+	query := insertQuery(dialect, i.Tablename, columns, [][]string{namedValues(columns)}, i.Returning)
+
+	return query + terminatorOf(dialect), nil
+}
+
+// columns returns the inserted columns.
+func (i *Insert) columns(args *namedArgs) ([]string, error) {
+	columns := args.writableColumns()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: insert into %q has no columns", ErrInvalidArg, i.Tablename)
+	}
 
-	case *namedArgs:
-		query, err := i.generateInsertQuery(dialect, args)
+	return columns, nil
+}
+
+// rebindReturningInto rebinds an insert query, which returns the columns of the inserted row into
+// out parameters pointing to the fields of the model. The model must be a pointer to a struct.
+func (i Insert) rebindReturningInto(dialect Dialect, naming *naming,
+	args *namedArgs) (string, []any, error) {
+	columns, err := i.columns(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	insert := insertQuery(dialect, i.Tablename, columns, [][]string{namedValues(columns)}, false)
+
+	query, params, err := rebindQuery(dialect, naming, insert, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// fields within nil pointer structs cannot be returned into
+	returned := args.lookupMap.columns(func(field fieldInfo) bool {
+		return args.field(field).IsValid()
+	})
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(query)
+	buffer.WriteString(" returning ")
+	writeIdentifierList(&buffer, dialect, returned)
+	buffer.WriteString(" into ")
+
+	for j, column := range returned {
+		if j > 0 {
+			buffer.WriteString(", ")
+		}
+
+		buffer.WriteString(dialect.Placeholder(len(params)))
+		params = append(params, sql.Out{Dest: args.field(args.lookupMap[column]).Addr().Interface()})
+	}
+
+	buffer.WriteString(terminatorOf(dialect))
+	return buffer.String(), params, nil
+}
+
+// InsertBatch generates insert queries of multiple rows from a slice of structs.
+// The rows are split into as few queries as possible without exceeding the parameter and row limits
+// of the Dialect (see Capabilities). Exec runs all queries within a
+// single transaction, which is nested into the transaction of the context if present (see Begin).
+// Fields tagged with the `auto` or `readonly` option are skipped, as well as fields tagged with the
+// `omitempty` option, which have a zero value. Because all rows of a query share the same columns,
+// rows skipping different columns are inserted by separate queries.
+type InsertBatch struct {
+	requireExplicitFields
+
+	Tablename string
+	// Models is a slice of structs or pointers to structs.
+	Models any
+}
+
+func (b InsertBatch) rebind(dialect Dialect, naming *naming) (string, []any, error) {
+	queries, err := b.rebindBatch(dialect, naming)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(queries) != 1 {
+		return "", nil, fmt.Errorf("%w: batch insert into %q requires %d queries, use Exec instead",
+			ErrInvalidArg, b.Tablename, len(queries))
+	}
+
+	return queries[0].query, queries[0].params, nil
+}
+
+func (b InsertBatch) rebindBatch(dialect Dialect, naming *naming) ([]reboundQuery, error) {
+	models := reflect.Indirect(reflect.ValueOf(b.Models))
+	if models.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: batch insert into %q expects a slice, got %T",
+			ErrInvalidArg, b.Tablename, b.Models)
+	}
+
+	lookupMap, err := naming.lookup(models.Type().Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	columns := lookupMap.columns(func(field fieldInfo) bool {
+		return field.writable()
+	})
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: batch insert into %q has no columns", ErrInvalidArg, b.Tablename)
+	}
+
+	capabilities := CapabilitiesOf(dialect)
+
+	rowsPerQuery := capabilities.MaxParameters / len(columns)
+	if rowsPerQuery < 1 {
+		rowsPerQuery = 1
+	}
+
+	if maxRows := capabilities.MaxInsertRows; maxRows > 0 && rowsPerQuery > maxRows {
+		rowsPerQuery = maxRows
+	}
+
+	var queries []reboundQuery
+
+	for offset := 0; offset < models.Len(); offset += rowsPerQuery {
+		end := offset + rowsPerQuery
+		if end > models.Len() {
+			end = models.Len()
+		}
+
+		groups, err := b.groupRows(lookupMap, models.Slice(offset, end))
 		if err != nil {
-			return "", nil, err
+			return nil, err
 		}
 
-		return rebindQuery(dialect, query, args)
+		for _, group := range groups {
+			query, err := b.rebindRows(dialect, lookupMap, group.columns, group.rows)
+			if err != nil {
+				return nil, err
+			}
 
+			queries = append(queries, query)
+		}
 	}
 
-	return "", nil, ErrInvalidArg
+	return queries, nil
 }
 
-func (i *Insert) generateInsertQuery(dialect Dialect, args *namedArgs) (string, error) {
-	var buffer bytes.Buffer
+// rowGroup is a group of rows, which insert the same columns.
+type rowGroup struct {
+	columns []string
+	rows    []reflect.Value
+}
+
+// groupRows groups the rows by their inserted columns. The groups are ordered by their first row.
+func (b InsertBatch) groupRows(lookupMap fieldLookupMap, rows reflect.Value) ([]rowGroup, error) {
+	var (
+		groups  []rowGroup
+		indices = make(map[string]int)
+	)
+
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		if !row.IsValid() {
+			return nil, fmt.Errorf("%w: batch insert into %q contains nil",
+				ErrInvalidArg, b.Tablename)
+		}
+
+		columns := (&namedArgs{lookupMap: lookupMap, value: row}).writableColumns()
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("%w: batch insert into %q contains a row without columns",
+				ErrInvalidArg, b.Tablename)
+		}
+
+		// column names are valid field names, which never contain commas
+		key := strings.Join(columns, ",")
+
+		index, ok := indices[key]
+		if !ok {
+			index = len(groups)
+			indices[key] = index
+			groups = append(groups, rowGroup{columns: columns})
+		}
 
-	columns := make([]string, 0, len(args.lookupMap))
-	for name := range args.lookupMap {
-		columns = append(columns, name)
+		groups[index].rows = append(groups[index].rows, row)
 	}
 
-	sort.Strings(columns)
+	return groups, nil
+}
+
+func (b InsertBatch) rebindRows(dialect Dialect, lookupMap fieldLookupMap, columns []string,
+	rows []reflect.Value) (reboundQuery, error) {
+	var (
+		values = make([][]string, len(rows))
+		args   positionalArgs
+	)
 
+	for i, row := range rows {
+		values[i] = make([]string, len(columns))
+
+		for j, column := range columns {
+			// fields within nil pointer structs are invalid and inserted as null
+			value := fieldByIndexOrInvalid(row, lookupMap[column].index)
+
+			values[i][j] = "@" + strconv.Itoa(len(args))
+			args = append(args, valueOrNil(value))
+		}
+	}
+
+	query := insertQuery(dialect, b.Tablename, columns, values, false) + terminatorOf(dialect)
+
+	// positional arguments do not depend on the naming
+	query, params, err := rebindQuery(dialect, defaultNaming, query, args)
+	if err != nil {
+		return reboundQuery{}, err
+	}
+
+	return reboundQuery{query: query, params: params}, nil
+}
+
+// writeInsert writes an insert query of rows without a trailing semicolon.
+func writeInsert(buffer *bytes.Buffer, dialect Dialect, tablename string, columns []string,
+	rows ...[]string) {
 	buffer.WriteString("insert into ")
-	buffer.WriteString(dialect.QuoteIdentifier(i.Tablename))
+	buffer.WriteString(dialect.QuoteIdentifier(tablename))
 	buffer.WriteString(" (")
+	writeIdentifierList(buffer, dialect, columns)
+	buffer.WriteString(") values ")
+	writeValueRows(buffer, rows)
+}
 
-	for i, column := range columns {
+// writeValueRows writes a comma separated list of rows of values (eg. `(a, b), (c, d)`).
+func writeValueRows(buffer *bytes.Buffer, rows [][]string) {
+	for i, row := range rows {
 		if i > 0 {
 			buffer.WriteString(", ")
 		}
 
-		buffer.WriteString(dialect.QuoteIdentifier(column))
+		buffer.WriteString("(")
+
+		for j, value := range row {
+			if j > 0 {
+				buffer.WriteString(", ")
+			}
+
+			buffer.WriteString(value)
+		}
+
+		buffer.WriteString(")")
+	}
+}
+
+func writeIdentifierList(buffer *bytes.Buffer, dialect Dialect, identifiers []string) {
+	for i, identifier := range identifiers {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		buffer.WriteString(dialect.QuoteIdentifier(identifier))
+	}
+}
+
+// namedValues returns the named parameter for each column.
+func namedValues(columns []string) []string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = "@" + column
+	}
+
+	return values
+}
+
+// Upsert generates an insert query from the fields of a struct, which updates the existing row
+// instead, when the insert would conflict with a unique constraint.
+// Upsert requires the Dialect to implement UpsertDialect and to support upserts (see
+// Capabilities).
+type Upsert struct {
+	requireExplicitFields
+
+	Tablename string
+	Model     Struct
+	// Conflict is the list of columns of the unique constraint, which decides whether to insert or
+	// update. Some databases (eg. MySQL) consider all unique constraints regardless.
+	// When empty, the fields tagged with the `pk` option are used.
+	// The Conflict columns are always inserted, even if tagged with the `auto` or `omitempty` option.
+	Conflict []string
+	// Update restricts the set of updated columns.
+	// When empty, all inserted columns except the Conflict columns are updated.
+	Update []string
+}
+
+func (u Upsert) rebind(dialect Dialect, naming *naming) (string, []any, error) {
+	args, err := namedModel(u.Model, naming)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, err := u.generateUpsertQuery(dialect, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rebindQuery(dialect, naming, query, args)
+}
+
+func (u *Upsert) generateUpsertQuery(dialect Dialect, args *namedArgs) (string, error) {
+	upsertDialect, ok := dialect.(UpsertDialect)
+	if !ok || CapabilitiesOf(dialect).Upsert == UpsertUnsupported {
+		return "", fmt.Errorf("%w: upsert into %q", ErrUnsupported, u.Tablename)
+	}
+
+	conflict := u.Conflict
+	if len(conflict) == 0 {
+		conflict = args.primaryKey()
+	}
+
+	if len(conflict) == 0 {
+		return "", fmt.Errorf("%w: upsert into %q requires conflict columns", ErrInvalidArg, u.Tablename)
+	}
+
+	if err := args.checkColumns(conflict); err != nil {
+		return "", err
+	}
+
+	columns := includeColumns(args.writableColumns(), conflict)
+
+	update := u.Update
+	if len(update) == 0 {
+		update = columns
+	} else if err := args.checkWritableColumns(update); err != nil {
+		return "", err
+	}
+
+	update = excludeColumns(update, conflict)
+	query := upsertDialect.UpsertQuery(u.Tablename, columns, namedValues(columns), conflict, update)
+
+	return query + terminatorOf(dialect), nil
+}
+
+// Update generates an update query from the fields of a struct.
+// The row to update is identified by the Key columns, which are never updated themselves.
+// Fields tagged with the `auto` or `readonly` option are skipped, as well as fields tagged with the
+// `omitempty` option, which have a zero value.
+type Update struct {
+	requireExplicitFields
+
+	Tablename string
+	Model     Struct
+	// Key is the list of columns used in the where clause to identify the row.
+	// When empty, the fields tagged with the `pk` option are used.
+	Key []string
+	// Columns restricts the set of updated columns.
+	// When empty, all writable columns except the Key are updated.
+	Columns []string
+}
+
+func (u Update) rebind(dialect Dialect, naming *naming) (string, []any, error) {
+	args, err := namedModel(u.Model, naming)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, err := u.generateUpdateQuery(dialect, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rebindQuery(dialect, naming, query, args)
+}
+
+func (u *Update) generateUpdateQuery(dialect Dialect, args *namedArgs) (string, error) {
+	key := u.Key
+	if len(key) == 0 {
+		key = args.primaryKey()
+	}
+
+	if len(key) == 0 {
+		return "", fmt.Errorf("%w: update of %q requires a key", ErrInvalidArg, u.Tablename)
+	}
+
+	if err := args.checkColumns(key); err != nil {
+		return "", err
+	}
+
+	columns := u.Columns
+	if len(columns) == 0 {
+		columns = args.writableColumns()
+	} else if err := args.checkWritableColumns(columns); err != nil {
+		return "", err
 	}
 
-	buffer.WriteString(") values (")
+	columns = excludeColumns(columns, key)
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%w: update of %q has no columns to set", ErrInvalidArg, u.Tablename)
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString("update ")
+	buffer.WriteString(dialect.QuoteIdentifier(u.Tablename))
+	buffer.WriteString(" set ")
+	writeAssignments(&buffer, dialect, columns, ", ")
+	buffer.WriteString(" where ")
+	writeAssignments(&buffer, dialect, key, " and ")
+	buffer.WriteString(terminatorOf(dialect))
+
+	return buffer.String(), nil
+}
 
+// writeAssignments writes a list of `column = @column` expressions separated by sep.
+func writeAssignments(buffer *bytes.Buffer, dialect Dialect, columns []string, sep string) {
 	for i, column := range columns {
 		if i > 0 {
-			buffer.WriteString(", ")
+			buffer.WriteString(sep)
 		}
 
-		buffer.WriteString("@")
+		buffer.WriteString(dialect.QuoteIdentifier(column))
+		buffer.WriteString(" = @")
 		buffer.WriteString(column)
 	}
+}
 
-	buffer.WriteString(")")
+// includeColumns returns the sorted columns including the included columns.
+func includeColumns(columns, included []string) []string {
+	merged := append([]string(nil), columns...)
 
-	if i.Returning {
-		buffer.WriteString(" returning *")
+	for _, column := range included {
+		if !containsColumn(merged, column) {
+			merged = append(merged, column)
+		}
 	}
 
-	buffer.WriteString(" ;")
-	return buffer.String(), nil
+	sort.Strings(merged)
+	return merged
+}
+
+func excludeColumns(columns, excluded []string) []string {
+	filtered := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		if !containsColumn(excluded, column) {
+			filtered = append(filtered, column)
+		}
+	}
+
+	return filtered
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
 }
@@ -1,9 +1,13 @@
 package noorm
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInsert(t *testing.T) {
@@ -23,12 +27,613 @@ func TestInsert(t *testing.T) {
 	}
 
 	for dialect, expectedQuery := range map[Dialect]string{
-		sqliteDialect{}: `insert into "table" ("id", "name") values (?, ?) ;`,
-		mysqlDialect{}:  "insert into `table` (`id`, `name`) values (?, ?) ;",
+		sqliteDialect{}:    `insert into "table" ("id", "name") values (?, ?) ;`,
+		mysqlDialect{}:     "insert into `table` (`id`, `name`) values (?, ?) ;",
+		sqlserverDialect{}: `insert into [table] ([id], [name]) values (@p1, @p2) ;`,
+		oracleDialect{}:    `insert into "TABLE" ("ID", "NAME") values (:1, :2)`,
 	} {
-		actualQuery, params, err := insert.rebind(dialect)
+		actualQuery, params, err := insert.rebind(dialect, defaultNaming)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedQuery, actualQuery)
 		assert.Equal(t, []any{int64(123), "Tester"}, params)
 	}
 }
+
+func TestInsertOptions(t *testing.T) {
+	type TestStruct struct {
+		ID       int64  `db:"id,pk,auto"`
+		Name     string `db:"name"`
+		Email    string `db:"email,omitempty"`
+		Computed string `db:"computed,readonly"`
+		Ignored  string `db:"-"`
+	}
+
+	for _, tc := range []struct {
+		model      TestStruct
+		query      string
+		parameters []any
+	}{
+		{
+			model:      TestStruct{ID: 1, Name: "Tester", Computed: "x", Ignored: "y"},
+			query:      `insert into "table" ("name") values (?) ;`,
+			parameters: []any{"Tester"},
+		},
+		{
+			model:      TestStruct{Name: "Tester", Email: "tester@example.com"},
+			query:      `insert into "table" ("email", "name") values (?, ?) ;`,
+			parameters: []any{"tester@example.com", "Tester"},
+		},
+	} {
+		insert := Insert{Tablename: "table", Model: tc.model}
+		actualQuery, params, err := insert.rebind(sqliteDialect{}, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.query, actualQuery)
+		assert.Equal(t, tc.parameters, params)
+	}
+}
+
+func TestInsertNoColumns(t *testing.T) {
+	type TestStruct struct {
+		ID      int64  `db:"id,pk,auto"`
+		Created string `db:"created,readonly"`
+		Note    string `db:"note,omitempty"`
+	}
+
+	model := TestStruct{}
+	insert := Insert{Tablename: "table", Model: &model}
+
+	_, _, err := insert.rebind(sqliteDialect{}, defaultNaming)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	args, err := namedModel(&model, defaultNaming)
+	require.NoError(t, err)
+
+	_, _, err = insert.rebindReturningInto(oracleDialect{}, defaultNaming, args)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
+
+func TestInsertReturning(t *testing.T) {
+	insert := Insert{
+		Tablename: "table",
+		Model:     testStructUser{ID: 1, Name: "a"},
+		Returning: true,
+	}
+
+	for dialect, expectedQuery := range map[Dialect]string{
+		sqliteDialect{}:    `insert into "table" ("id", "name") values (?, ?) returning * ;`,
+		postgresDialect{}:  `insert into "table" ("id", "name") values ($1, $2) returning * ;`,
+		sqlserverDialect{}: `insert into [table] ([id], [name]) output inserted.* values (@p1, @p2) ;`,
+	} {
+		actualQuery, params, err := insert.rebind(dialect, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedQuery, actualQuery)
+		assert.Equal(t, []any{1, "a"}, params)
+	}
+
+	for _, dialect := range []Dialect{mysqlDialect{}, oracleDialect{}, defaultDialect{}} {
+		_, _, err := insert.rebind(dialect, defaultNaming)
+		assert.ErrorIs(t, err, ErrUnsupported, "dialect=%T", dialect)
+	}
+}
+
+func TestInsertReturningInto(t *testing.T) {
+	type TestStruct struct {
+		ID      int64  `db:"id,pk,auto"`
+		Name    string `db:"name"`
+		Created string `db:"created,readonly"`
+	}
+
+	model := TestStruct{Name: "a"}
+
+	args, err := namedModel(&model, defaultNaming)
+	require.NoError(t, err)
+
+	query, params, err := Insert{Tablename: "table", Model: &model}.
+		rebindReturningInto(oracleDialect{}, defaultNaming, args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `insert into "TABLE" ("NAME") values (:1)`+
+		` returning "CREATED", "ID", "NAME" into :2, :3, :4`, query)
+	assert.Equal(t, []any{
+		"a",
+		sql.Out{Dest: &model.Created},
+		sql.Out{Dest: &model.ID},
+		sql.Out{Dest: &model.Name},
+	}, params)
+
+	// the out parameters point to the fields of the model
+	*params[2].(sql.Out).Dest.(*int64) = 42
+	assert.Equal(t, int64(42), model.ID)
+}
+
+func TestInsertReturningIntoOutParameters(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	defer sqlDB.Close()
+
+	db := New(sqlDB, Oracle())
+
+	// the interceptor plays the role of the driver, which assigns the out parameters
+	db.SetInterceptors(func(ctx context.Context, stmt *Statement, next Handler) (Outcome, error) {
+		*stmt.Params[2].(sql.Out).Dest.(*int) = 42
+		*stmt.Params[3].(sql.Out).Dest.(*string) = "returned"
+
+		return Outcome{Result: driver.RowsAffected(1)}, nil
+	})
+
+	model := testStructUser{Name: "a"}
+
+	err = InsertReturning(WithDatabase(context.Background(), db), Insert{
+		Tablename: "users",
+		Model:     &model,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, testStructUser{ID: 42, Name: "returned"}, model)
+}
+
+func TestUpdate(t *testing.T) {
+	type TestStruct struct {
+		ID    int64  `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	testStruct := TestStruct{
+		ID:    123,
+		Name:  "Tester",
+		Email: "tester@example.com",
+	}
+
+	for _, tc := range []struct {
+		update     Update
+		dialect    Dialect
+		query      string
+		parameters []any
+	}{
+		{
+			update: Update{
+				Tablename: "table",
+				Model:     testStruct,
+				Key:       []string{"id"},
+			},
+			dialect:    sqliteDialect{},
+			query:      `update "table" set "email" = ?, "name" = ? where "id" = ? ;`,
+			parameters: []any{"tester@example.com", "Tester", int64(123)},
+		},
+		{
+			update: Update{
+				Tablename: "table",
+				Model:     &testStruct,
+				Key:       []string{"id"},
+				Columns:   []string{"name"},
+			},
+			dialect:    postgresDialect{},
+			query:      `update "table" set "name" = $1 where "id" = $2 ;`,
+			parameters: []any{"Tester", int64(123)},
+		},
+		{
+			update: Update{
+				Tablename: "table",
+				Model:     testStruct,
+				Key:       []string{"id", "email"},
+			},
+			dialect:    mysqlDialect{},
+			query:      "update `table` set `name` = ? where `id` = ? and `email` = ? ;",
+			parameters: []any{"Tester", int64(123), "tester@example.com"},
+		},
+		{
+			update: Update{
+				Tablename: "table",
+				Model:     testStruct,
+				Key:       []string{"id"},
+			},
+			dialect:    sqlserverDialect{},
+			query:      `update [table] set [email] = @p1, [name] = @p2 where [id] = @p3 ;`,
+			parameters: []any{"tester@example.com", "Tester", int64(123)},
+		},
+		{
+			update: Update{
+				Tablename: "table",
+				Model:     testStruct,
+				Key:       []string{"id"},
+			},
+			dialect:    oracleDialect{},
+			query:      `update "TABLE" set "EMAIL" = :1, "NAME" = :2 where "ID" = :3`,
+			parameters: []any{"tester@example.com", "Tester", int64(123)},
+		},
+	} {
+		actualQuery, params, err := tc.update.rebind(tc.dialect, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.query, actualQuery)
+		assert.Equal(t, tc.parameters, params)
+	}
+}
+
+func TestUpdateOptions(t *testing.T) {
+	type TestStruct struct {
+		ID       int64  `db:"id,pk,auto"`
+		Name     string `db:"name"`
+		Email    string `db:"email,omitempty"`
+		Computed string `db:"computed,readonly"`
+	}
+
+	update := Update{
+		Tablename: "table",
+		Model:     TestStruct{ID: 123, Name: "Tester"},
+	}
+
+	actualQuery, params, err := update.rebind(sqliteDialect{}, defaultNaming)
+	assert.NoError(t, err)
+	assert.Equal(t, `update "table" set "name" = ? where "id" = ? ;`, actualQuery)
+	assert.Equal(t, []any{"Tester", int64(123)}, params)
+
+	update.Columns = []string{"computed"}
+	_, _, err = update.rebind(sqliteDialect{}, defaultNaming)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
+
+func TestUpdateInvalid(t *testing.T) {
+	type TestStruct struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	for _, update := range []Update{
+		{Tablename: "table", Model: TestStruct{}},
+		{Tablename: "table", Model: TestStruct{}, Key: []string{"unknown"}},
+		{Tablename: "table", Model: TestStruct{}, Key: []string{"id"}, Columns: []string{"unknown"}},
+		{Tablename: "table", Model: TestStruct{}, Key: []string{"id"}, Columns: []string{"id"}},
+	} {
+		_, _, err := update.rebind(sqliteDialect{}, defaultNaming)
+		assert.ErrorIs(t, err, ErrInvalidArg)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	type TestStruct struct {
+		ID    int64  `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+
+	testStruct := TestStruct{
+		ID:    123,
+		Name:  "Tester",
+		Email: "tester@example.com",
+	}
+
+	for _, tc := range []struct {
+		upsert  Upsert
+		dialect Dialect
+		query   string
+	}{
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+			},
+			dialect: sqliteDialect{},
+			query: `insert into "table" ("email", "id", "name") values (?, ?, ?)` +
+				` on conflict ("id") do update set "email" = excluded."email", "name" = excluded."name" ;`,
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+				Update:    []string{"name"},
+			},
+			dialect: postgresDialect{},
+			query: `insert into "table" ("email", "id", "name") values ($1, $2, $3)` +
+				` on conflict ("id") do update set "name" = excluded."name" ;`,
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+				Update:    []string{"id"},
+			},
+			dialect: postgresDialect{},
+			query: `insert into "table" ("email", "id", "name") values ($1, $2, $3)` +
+				` on conflict ("id") do nothing ;`,
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+			},
+			dialect: mysqlDialect{},
+			query: "insert into `table` (`email`, `id`, `name`) values (?, ?, ?)" +
+				" on duplicate key update `email` = values(`email`), `name` = values(`name`) ;",
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+				Update:    []string{"id"},
+			},
+			dialect: mysqlDialect{},
+			query: "insert into `table` (`email`, `id`, `name`) values (?, ?, ?)" +
+				" on duplicate key update `id` = `id` ;",
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+			},
+			dialect: sqlserverDialect{},
+			query: `merge into [table] with (holdlock) as target` +
+				` using (values (@p1, @p2, @p3)) as source ([email], [id], [name])` +
+				` on (target.[id] = source.[id])` +
+				` when matched then update set` +
+				` target.[email] = source.[email], target.[name] = source.[name]` +
+				` when not matched then insert ([email], [id], [name])` +
+				` values (source.[email], source.[id], source.[name]) ;`,
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id"},
+				Update:    []string{"id"},
+			},
+			dialect: sqlserverDialect{},
+			query: `merge into [table] with (holdlock) as target` +
+				` using (values (@p1, @p2, @p3)) as source ([email], [id], [name])` +
+				` on (target.[id] = source.[id])` +
+				` when not matched then insert ([email], [id], [name])` +
+				` values (source.[email], source.[id], source.[name]) ;`,
+		},
+		{
+			upsert: Upsert{
+				Tablename: "table",
+				Model:     testStruct,
+				Conflict:  []string{"id", "email"},
+				Update:    []string{"name"},
+			},
+			dialect: oracleDialect{},
+			query: `merge into "TABLE" target` +
+				` using (select :1 "EMAIL", :2 "ID", :3 "NAME" from dual) source` +
+				` on (target."ID" = source."ID" and target."EMAIL" = source."EMAIL")` +
+				` when matched then update set target."NAME" = source."NAME"` +
+				` when not matched then insert ("EMAIL", "ID", "NAME")` +
+				` values (source."EMAIL", source."ID", source."NAME")`,
+		},
+	} {
+		actualQuery, params, err := tc.upsert.rebind(tc.dialect, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.query, actualQuery)
+		assert.Equal(t, []any{"tester@example.com", int64(123), "Tester"}, params)
+	}
+}
+
+func TestUpsertOptions(t *testing.T) {
+	type TestStruct struct {
+		Key     string `db:"key,pk"`
+		Value   string `db:"value"`
+		Created string `db:"created,readonly"`
+	}
+
+	actualQuery, params, err := Upsert{
+		Tablename: "table",
+		Model:     TestStruct{Key: "k", Value: "v"},
+	}.rebind(sqliteDialect{}, defaultNaming)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `insert into "table" ("key", "value") values (?, ?)`+
+		` on conflict ("key") do update set "value" = excluded."value" ;`, actualQuery)
+	assert.Equal(t, []any{"k", "v"}, params)
+}
+
+func TestUpsertAuto(t *testing.T) {
+	type TestStruct struct {
+		ID    int64  `db:"id,pk,auto"`
+		Name  string `db:"name"`
+		Email string `db:"email,omitempty"`
+	}
+
+	testStruct := TestStruct{ID: 1, Name: "Tester"}
+
+	for _, tc := range []struct {
+		upsert  Upsert
+		dialect Dialect
+		query   string
+		params  []any
+	}{
+		{
+			upsert:  Upsert{Tablename: "table", Model: testStruct},
+			dialect: sqliteDialect{},
+			query: `insert into "table" ("id", "name") values (?, ?)` +
+				` on conflict ("id") do update set "name" = excluded."name" ;`,
+			params: []any{int64(1), "Tester"},
+		},
+		{
+			upsert:  Upsert{Tablename: "table", Model: testStruct},
+			dialect: sqlserverDialect{},
+			query: `merge into [table] with (holdlock) as target` +
+				` using (values (@p1, @p2)) as source ([id], [name])` +
+				` on (target.[id] = source.[id])` +
+				` when matched then update set target.[name] = source.[name]` +
+				` when not matched then insert ([id], [name])` +
+				` values (source.[id], source.[name]) ;`,
+			params: []any{int64(1), "Tester"},
+		},
+		{
+			upsert:  Upsert{Tablename: "table", Model: testStruct, Conflict: []string{"email"}},
+			dialect: oracleDialect{},
+			query: `merge into "TABLE" target` +
+				` using (select :1 "EMAIL", :2 "NAME" from dual) source` +
+				` on (target."EMAIL" = source."EMAIL")` +
+				` when matched then update set target."NAME" = source."NAME"` +
+				` when not matched then insert ("EMAIL", "NAME")` +
+				` values (source."EMAIL", source."NAME")`,
+			params: []any{"", "Tester"},
+		},
+	} {
+		actualQuery, params, err := tc.upsert.rebind(tc.dialect, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.query, actualQuery)
+		assert.Equal(t, tc.params, params)
+	}
+}
+
+func TestUpsertUnsupported(t *testing.T) {
+	type unsupportedDialect struct {
+		Dialect
+	}
+
+	_, _, err := Upsert{
+		Tablename: "table",
+		Model:     testStructUser{},
+		Conflict:  []string{"id"},
+	}.rebind(unsupportedDialect{defaultDialect{}}, defaultNaming)
+
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+type testLimitedDialect struct {
+	defaultDialect
+	maxParameters int
+}
+
+func (d testLimitedDialect) Capabilities() Capabilities {
+	capabilities := d.defaultDialect.Capabilities()
+	capabilities.MaxParameters = d.maxParameters
+
+	return capabilities
+}
+
+func TestInsertBatch(t *testing.T) {
+	type TestStruct struct {
+		ID   int64  `db:"id,auto"`
+		Name string `db:"name"`
+		Note string `db:"note,omitempty"`
+	}
+
+	models := []TestStruct{
+		{Name: "a", Note: "x"},
+		{Name: "b"},
+		{Name: "c", Note: "z"},
+	}
+
+	for _, tc := range []struct {
+		dialect Dialect
+		queries []reboundQuery
+	}{
+		{
+			// rows skipping the omitempty field are inserted separately
+			dialect: sqliteDialect{},
+			queries: []reboundQuery{
+				{
+					query:  `insert into "table" ("name", "note") values (?, ?), (?, ?) ;`,
+					params: []any{"a", "x", "c", "z"},
+				},
+				{
+					query:  `insert into "table" ("name") values (?) ;`,
+					params: []any{"b"},
+				},
+			},
+		},
+		{
+			dialect: testLimitedDialect{maxParameters: 5},
+			queries: []reboundQuery{
+				{
+					query:  `insert into "table" ("name", "note") values (?, ?) ;`,
+					params: []any{"a", "x"},
+				},
+				{
+					query:  `insert into "table" ("name") values (?) ;`,
+					params: []any{"b"},
+				},
+				{
+					query:  `insert into "table" ("name", "note") values (?, ?) ;`,
+					params: []any{"c", "z"},
+				},
+			},
+		},
+		{
+			dialect: sqlserverDialect{},
+			queries: []reboundQuery{
+				{
+					query:  `insert into [table] ([name], [note]) values (@p1, @p2), (@p3, @p4) ;`,
+					params: []any{"a", "x", "c", "z"},
+				},
+				{
+					query:  `insert into [table] ([name]) values (@p1) ;`,
+					params: []any{"b"},
+				},
+			},
+		},
+		{
+			dialect: oracleDialect{},
+			queries: []reboundQuery{
+				{
+					query: `insert all into "TABLE" ("NAME", "NOTE") values (:1, :2)` +
+						` into "TABLE" ("NAME", "NOTE") values (:3, :4) select 1 from dual`,
+					params: []any{"a", "x", "c", "z"},
+				},
+				{
+					query:  `insert into "TABLE" ("NAME") values (:1)`,
+					params: []any{"b"},
+				},
+			},
+		},
+	} {
+		batch := InsertBatch{Tablename: "table", Models: models}
+		queries, err := batch.rebindBatch(tc.dialect, defaultNaming)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.queries, queries)
+	}
+}
+
+func TestInsertBatchNilStruct(t *testing.T) {
+	type Address struct {
+		Street string `db:"street"`
+	}
+
+	type Node struct {
+		ID int `db:"id"`
+		*Address
+		Next *testStructUser `db:"next,prefix=next_"`
+	}
+
+	models := []Node{{ID: 1}, {ID: 2, Address: &Address{Street: "a"}, Next: &testStructUser{ID: 3}}}
+
+	batch := InsertBatch{Tablename: "table", Models: models}
+	query, params, err := batch.rebind(sqliteDialect{}, defaultNaming)
+	assert.NoError(t, err)
+	assert.Equal(t, `insert into "table" ("id", "next_id", "next_name", "street")`+
+		` values (?, ?, ?, ?), (?, ?, ?, ?) ;`, query)
+	assert.Equal(t, []any{1, nil, nil, nil, 2, 3, "", "a"}, params)
+}
+
+func TestInsertBatchRebind(t *testing.T) {
+	models := []*testStructUser{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}
+
+	batch := InsertBatch{Tablename: "table", Models: models}
+	query, params, err := batch.rebind(postgresDialect{}, defaultNaming)
+	assert.NoError(t, err)
+	assert.Equal(t, `insert into "table" ("id", "name") values ($1, $2), ($3, $4) ;`, query)
+	assert.Equal(t, []any{1, "a", 2, "b"}, params)
+
+	_, _, err = batch.rebind(testLimitedDialect{maxParameters: 2}, defaultNaming)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	// sql server limits the rows of a values clause regardless of the parameters
+	batch.Models = make([]testStructUser, 1001)
+	queries, err := batch.rebindBatch(sqlserverDialect{}, defaultNaming)
+	assert.NoError(t, err)
+	assert.Len(t, queries, 2)
+	assert.Len(t, queries[1].params, 2)
+
+	batch.Models = testStructUser{}
+	_, _, err = batch.rebind(postgresDialect{}, defaultNaming)
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
@@ -0,0 +1,344 @@
+package noorm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sqlSyntax describes the lexical rules of a dialect, which are required to tell sql code apart from
+// literals, quoted identifiers and comments.
+type sqlSyntax struct {
+	// backslashEscapes permits escaping quotes with a backslash within string literals.
+	backslashEscapes bool
+	// escapeStrings permits escaping quotes with a backslash within string literals prefixed by
+	// `E` (eg. `E'it\'s'`).
+	escapeStrings bool
+	// doubleQuotedStrings treats double quotes as string literals instead of identifiers.
+	doubleQuotedStrings bool
+	// backtickIdentifiers permits quoting identifiers with backticks.
+	backtickIdentifiers bool
+	// bracketIdentifiers permits quoting identifiers with square brackets (`]` is escaped as `]]`).
+	bracketIdentifiers bool
+	// dollarQuotes permits dollar quoted string literals (eg. `$body$ ... $body$`).
+	dollarQuotes bool
+	// alternativeQuotes permits string literals prefixed by `q` with custom delimiters
+	// (eg. `q'[it's]'`).
+	alternativeQuotes bool
+	// hashComments permits line comments starting with `#`.
+	hashComments bool
+	// dashCommentNeedsSpace requires a whitespace after `--` to start a line comment.
+	dashCommentNeedsSpace bool
+	// nestedComments permits nesting block comments.
+	nestedComments bool
+}
+
+type syntaxDialect interface {
+	syntax() sqlSyntax
+}
+
+func syntaxOf(dialect Dialect) sqlSyntax {
+	if syntaxDialect, ok := dialect.(syntaxDialect); ok {
+		return syntaxDialect.syntax()
+	}
+
+	return sqlSyntax{}
+}
+
+// rebindQuery parses the query and replaces named paremeters with the database specific
+// placeholder. Named parameters have the form `@name` where `name` is the actual name.
+// Only letters, numbers, dashes and underscores are permitted as names, with dots separating the
+// names of nested structs. Named arguments are resolved using the naming of the database.
+// Parameters are only bound in sql code, so that string literals, quoted identifiers and comments
+// may contain `@`. A literal `@` can be written by doubling it `@@` anywhere in the query including
+// string literals, quoted identifiers and comments (eg. `'@@'` is passed as `'@'`).
+func rebindQuery(dialect Dialect, naming *naming, query string,
+	args ArgumentSource) (string, []any, error) {
+	const at = '@'
+
+	var (
+		syntax         = syntaxOf(dialect)
+		queryBuffer    bytes.Buffer
+		parameterSlice []any
+	)
+
+	args = withNaming(args, naming)
+
+	for offset := 0; offset < len(query); {
+		if end := syntax.skipNonCode(query, offset); end > offset {
+			// a doubled `@` is a literal `@` even outside of sql code for backwards compatibility
+			queryBuffer.WriteString(strings.ReplaceAll(query[offset:end], "@@", "@"))
+			offset = end
+			continue
+		}
+
+		if query[offset] != at {
+			queryBuffer.WriteByte(query[offset])
+			offset++
+			continue
+		}
+
+		if offset+1 < len(query) && query[offset+1] == at {
+			queryBuffer.WriteByte(at)
+			offset += 2
+			continue
+		}
+
+		end := scanParameterName(query, offset+1)
+		if end == offset+1 {
+			queryBuffer.WriteByte(at)
+			offset++
+			continue
+		}
+
+		arg, err := args.arg(query[offset+1 : end])
+		if err != nil {
+			return query, nil, err
+		}
+
+		argValues := splitArg(arg)
+		if len(argValues) == 0 && isNotInList(queryBuffer.String()) {
+			return query, nil, fmt.Errorf("%w: empty list %q within `not in`",
+				ErrInvalidArg, query[offset+1:end])
+		}
+
+		repeatPlaceholder(&queryBuffer, dialect, len(parameterSlice), len(argValues))
+
+		parameterSlice = append(parameterSlice, argValues...)
+		offset = end
+	}
+
+	return queryBuffer.String(), parameterSlice, nil
+}
+
+func isParameterNameRune(r rune) bool {
+	return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '-' || r == '_'
+}
+
+// scanParameterName returns the end of the parameter name starting at offset.
+// A dot within the name separates the names of nested structs (eg. `@author.id`).
+func scanParameterName(query string, offset int) int {
+	start := offset
+
+	for offset < len(query) {
+		if strings.HasPrefix(query[offset:], "--") {
+			// a line comment directly following the name
+			break
+		}
+
+		r, size := utf8.DecodeRuneInString(query[offset:])
+		if r == '.' && offset > start {
+			// a dot only continues the name, if another name follows
+			next, _ := utf8.DecodeRuneInString(query[offset+size:])
+			if !isParameterNameRune(next) || next == '-' {
+				break
+			}
+		} else if !isParameterNameRune(r) {
+			break
+		}
+
+		offset += size
+	}
+
+	return offset
+}
+
+// skipNonCode returns the end of the string literal, quoted identifier or comment starting at
+// offset. If there is none, offset is returned.
+func (s sqlSyntax) skipNonCode(query string, offset int) int {
+	rest := query[offset:]
+
+	switch rest[0] {
+	case '\'':
+		if s.alternativeQuotes && isAlternativeQuotePrefix(query, offset) {
+			return skipAlternativeQuoted(query, offset)
+		}
+
+		backslash := s.backslashEscapes || (s.escapeStrings && isEscapeStringPrefix(query, offset))
+		return skipQuoted(query, offset, '\'', backslash)
+
+	case '"':
+		if s.doubleQuotedStrings {
+			return skipQuoted(query, offset, '"', s.backslashEscapes)
+		}
+
+		return skipQuoted(query, offset, '"', false)
+
+	case '`':
+		if s.backtickIdentifiers {
+			return skipQuoted(query, offset, '`', false)
+		}
+
+	case '[':
+		if s.bracketIdentifiers {
+			return skipQuoted(query, offset, ']', false)
+		}
+
+	case '-':
+		if strings.HasPrefix(rest, "--") {
+			if !s.dashCommentNeedsSpace || len(rest) == 2 || isSpace(rest[2]) {
+				return skipUntil(query, offset+2, "\n")
+			}
+		}
+
+	case '#':
+		if s.hashComments {
+			return skipUntil(query, offset+1, "\n")
+		}
+
+	case '/':
+		if strings.HasPrefix(rest, "/*") {
+			return s.skipBlockComment(query, offset)
+		}
+
+	case '$':
+		if s.dollarQuotes && !isPrecededByIdentifier(query, offset) {
+			if tag := dollarQuoteTag(rest); tag != "" {
+				return skipUntil(query, offset+len(tag), tag)
+			}
+		}
+	}
+
+	return offset
+}
+
+func (s sqlSyntax) skipBlockComment(query string, offset int) int {
+	depth := 0
+
+	for offset < len(query) {
+		switch {
+		case strings.HasPrefix(query[offset:], "/*") && (depth == 0 || s.nestedComments):
+			depth++
+			offset += 2
+
+		case strings.HasPrefix(query[offset:], "*/"):
+			depth--
+			offset += 2
+
+			if depth == 0 {
+				return offset
+			}
+
+		default:
+			offset++
+		}
+	}
+
+	return offset
+}
+
+// skipQuoted returns the end of a quoted literal or identifier. Quotes are escaped by doubling them
+// or optionally by a backslash. Unterminated quotes reach until the end of the query.
+func skipQuoted(query string, offset int, quote byte, backslash bool) int {
+	for offset++; offset < len(query); offset++ {
+		switch query[offset] {
+		case '\\':
+			if backslash {
+				offset++
+			}
+
+		case quote:
+			if offset+1 < len(query) && query[offset+1] == quote {
+				offset++
+			} else {
+				return offset + 1
+			}
+		}
+	}
+
+	return len(query)
+}
+
+// skipUntil returns the end of the first occurrence of terminator at or after offset.
+// If there is none, the end of the query is returned.
+func skipUntil(query string, offset int, terminator string) int {
+	if end := strings.Index(query[offset:], terminator); end > -1 {
+		return offset + end + len(terminator)
+	}
+
+	return len(query)
+}
+
+// dollarQuoteTag returns the opening tag of a dollar quoted string (eg. `$$` or `$body$`).
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '$':
+			return s[:i+1]
+
+		case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= utf8.RuneSelf:
+			continue
+
+		case '0' <= c && c <= '9' && i > 1:
+			continue
+
+		default:
+			// not a tag, eg. a positional parameter `$1`
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// isEscapeStringPrefix reports whether the quote at offset is prefixed by a standalone `E`.
+func isEscapeStringPrefix(query string, offset int) bool {
+	return offset > 0 && (query[offset-1] == 'E' || query[offset-1] == 'e') &&
+		!isPrecededByIdentifier(query, offset-1)
+}
+
+// isAlternativeQuotePrefix reports whether the quote at offset is prefixed by `q` or `nq`.
+func isAlternativeQuotePrefix(query string, offset int) bool {
+	if offset == 0 || (query[offset-1] != 'q' && query[offset-1] != 'Q') {
+		return false
+	}
+
+	offset--
+
+	if offset > 0 && (query[offset-1] == 'n' || query[offset-1] == 'N') {
+		offset--
+	}
+
+	return !isPrecededByIdentifier(query, offset)
+}
+
+// skipAlternativeQuoted returns the end of a string literal with custom delimiters starting at the
+// quote at offset. The opening brackets `[`, `(`, `{` and `<` are closed by their counterpart, any
+// other delimiter by itself.
+func skipAlternativeQuoted(query string, offset int) int {
+	if offset+1 >= len(query) {
+		return len(query)
+	}
+
+	delimiter := query[offset+1]
+
+	switch delimiter {
+	case '[':
+		delimiter = ']'
+	case '(':
+		delimiter = ')'
+	case '{':
+		delimiter = '}'
+	case '<':
+		delimiter = '>'
+	}
+
+	return skipUntil(query, offset+2, string([]byte{delimiter, '\''}))
+}
+
+func isPrecededByIdentifier(query string, offset int) bool {
+	if offset == 0 {
+		return false
+	}
+
+	r, _ := utf8.DecodeLastRuneInString(query[:offset])
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,200 @@
+package noorm
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rebindTestCase struct {
+	query      string
+	expected   string
+	parameters []any
+}
+
+func TestRebindSyntax(t *testing.T) {
+	args := Map(map[string]any{
+		"a": 1,
+		"b": 2,
+	})
+
+	common := []rebindTestCase{
+		{
+			query:      `select 'user@example.com', @a ;`,
+			expected:   `select 'user@example.com', ? ;`,
+			parameters: []any{1},
+		},
+		{
+			query:      `select 'it''s @a', @b ;`,
+			expected:   `select 'it''s @a', ? ;`,
+			parameters: []any{2},
+		},
+		{
+			query:      "select @a -- @b is not bound\n, @b ;",
+			expected:   "select ? -- @b is not bound\n, ? ;",
+			parameters: []any{1, 2},
+		},
+		{
+			query:      `select /* @b */ @a ;`,
+			expected:   `select /* @b */ ? ;`,
+			parameters: []any{1},
+		},
+		{
+			query:    `select 1 ; -- @a at the end`,
+			expected: `select 1 ; -- @a at the end`,
+		},
+		{
+			query:      `select @a, @@b ;`,
+			expected:   `select ?, @b ;`,
+			parameters: []any{1},
+		},
+		{
+			query:      `select @a. ;`,
+			expected:   `select ?. ;`,
+			parameters: []any{1},
+		},
+		{
+			query:      "select @a.-- @b is not bound\n;",
+			expected:   "select ?.-- @b is not bound\n;",
+			parameters: []any{1},
+		},
+		{
+			query:    `select 'unterminated @a`,
+			expected: `select 'unterminated @a`,
+		},
+	}
+
+	for dialect, cases := range map[Dialect][]rebindTestCase{
+		sqliteDialect{}: append(common, []rebindTestCase{
+			{
+				query:      `select "col@a", [col@b], ` + "`col@a`" + `, @b ;`,
+				expected:   `select "col@a", [col@b], ` + "`col@a`" + `, ? ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select @a-- comment`,
+				expected:   `select ?-- comment`,
+				parameters: []any{1},
+			},
+		}...),
+		postgresDialect{}: append(numberPlaceholders(common, "$"), []rebindTestCase{
+			{
+				query:      `select "col@a", @b::int ;`,
+				expected:   `select "col@a", $1::int ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select E'it\'s @a', e'\\', @b ;`,
+				expected:   `select E'it\'s @a', e'\\', $1 ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select 'C:\', @a ;`,
+				expected:   `select 'C:\', $1 ;`,
+				parameters: []any{1},
+			},
+			{
+				query:      `create function f() returns text as $$ select '@a' $$ language sql ; select @b ;`,
+				expected:   `create function f() returns text as $$ select '@a' $$ language sql ; select $1 ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select $body$ $$ @a $$ $body$, @b ;`,
+				expected:   `select $body$ $$ @a $$ $body$, $1 ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select /* outer /* @a */ @b */ @a ;`,
+				expected:   `select /* outer /* @a */ @b */ $1 ;`,
+				parameters: []any{1},
+			},
+			{
+				query:      `select '{"a":1}'::jsonb @> '{}', @a ;`,
+				expected:   `select '{"a":1}'::jsonb @> '{}', $1 ;`,
+				parameters: []any{1},
+			},
+		}...),
+		mysqlDialect{}: append(common, []rebindTestCase{
+			{
+				query:      "select `col@a`, \"str@a\", @b ;",
+				expected:   "select `col@a`, \"str@a\", ? ;",
+				parameters: []any{2},
+			},
+			{
+				query:      `select 'it\'s @a', "\"@a", @b ;`,
+				expected:   `select 'it\'s @a', "\"@a", ? ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      "select @a # @b is not bound\n, @b ;",
+				expected:   "select ? # @b is not bound\n, ? ;",
+				parameters: []any{1, 2},
+			},
+			{
+				query:      `select 1--@a ;`,
+				expected:   `select 1--? ;`,
+				parameters: []any{1},
+			},
+			{
+				query:      `select @@@@version, @a ;`,
+				expected:   `select @@version, ? ;`,
+				parameters: []any{1},
+			},
+		}...),
+		sqlserverDialect{}: append(numberPlaceholders(common, "@p"), []rebindTestCase{
+			{
+				query:      `select "col@a", [col@a], [a]]@b], @b ;`,
+				expected:   `select "col@a", [col@a], [a]]@b], @p1 ;`,
+				parameters: []any{2},
+			},
+			{
+				query:      `select /* outer /* @a */ @b */ @a ;`,
+				expected:   `select /* outer /* @a */ @b */ @p1 ;`,
+				parameters: []any{1},
+			},
+			{
+				query:      `select N'it''s @a', @@rowcount, @b ;`,
+				expected:   `select N'it''s @a', @rowcount, @p1 ;`,
+				parameters: []any{2},
+			},
+		}...),
+		oracleDialect{}: append(numberPlaceholders(common, ":"), []rebindTestCase{
+			{
+				query:      `select "col@a", @b, @a from dual`,
+				expected:   `select "col@a", :1, :2 from dual`,
+				parameters: []any{2, 1},
+			},
+			{
+				query:      `select q'[it's @a]', Q'{@a}', nq'<@b>', q'!it's @a!', @b from dual`,
+				expected:   `select q'[it's @a]', Q'{@a}', nq'<@b>', q'!it's @a!', :1 from dual`,
+				parameters: []any{2},
+			},
+		}...),
+	} {
+		for _, tc := range cases {
+			query, parameters, err := rebindQuery(dialect, defaultNaming, tc.query, args)
+			require.NoError(t, err, "query=%q", tc.query)
+			assert.Equal(t, tc.expected, query, "dialect=%T", dialect)
+			assert.Equal(t, tc.parameters, parameters, "dialect=%T query=%q", dialect, tc.query)
+		}
+	}
+}
+
+// numberPlaceholders replaces the `?` placeholders of the expected queries with the prefix followed
+// by their number (eg. `$1`).
+func numberPlaceholders(cases []rebindTestCase, prefix string) []rebindTestCase {
+	numbered := make([]rebindTestCase, len(cases))
+
+	for i, tc := range cases {
+		for n := 1; strings.Contains(tc.expected, "?"); n++ {
+			tc.expected = strings.Replace(tc.expected, "?", prefix+strconv.Itoa(n), 1)
+		}
+
+		numbered[i] = tc
+	}
+
+	return numbered
+}
@@ -0,0 +1,170 @@
+package noorm
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Record is a row of a query, whose shape is not known at compile time.
+// Use Iterate[Record] or Query[Record] to scan rows into records. If the column types are not
+// needed, map[string]any can be used instead.
+type Record struct {
+	// Columns describes the columns of the row.
+	Columns []*sql.ColumnType
+	// Values are the values of each column in the same order as Columns.
+	Values []any
+}
+
+// Get returns the value of the first column with the provided name.
+func (r Record) Get(name string) (any, bool) {
+	for i, column := range r.Columns {
+		if column.Name() == name {
+			return r.Values[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Map returns the values by column name. Values of duplicate column names are overwritten by later
+// columns.
+func (r Record) Map() map[string]any {
+	values := make(map[string]any, len(r.Columns))
+	for i, column := range r.Columns {
+		values[column.Name()] = r.Values[i]
+	}
+
+	return values
+}
+
+var (
+	recordType = reflect.TypeOf(Record{})
+	mapType    = reflect.TypeOf(map[string]any(nil))
+)
+
+// recordScanner scans rows into a Record or a map[string]any.
+type recordScanner struct {
+	columns []*sql.ColumnType
+	asMap   bool
+}
+
+func newRecordScanner(rows *sql.Rows, asMap bool) (rowScanner, error) {
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	return recordScanner{columns: columns, asMap: asMap}, nil
+}
+
+func (s recordScanner) scan(rows *sql.Rows, value reflect.Value) error {
+	values := make([]any, len(s.columns))
+	targetSlice := make([]any, len(s.columns))
+
+	for i := range values {
+		targetSlice[i] = &values[i]
+	}
+
+	if err := rows.Scan(targetSlice...); err != nil {
+		return err
+	}
+
+	for i, column := range s.columns {
+		values[i] = convertDynamicValue(column, values[i])
+	}
+
+	record := Record{
+		Columns: s.columns,
+		Values:  values,
+	}
+
+	if s.asMap {
+		value.Set(reflect.ValueOf(record.Map()))
+	} else {
+		value.Set(reflect.ValueOf(record))
+	}
+
+	return nil
+}
+
+var (
+	// binaryTypeNames are database type names of columns, whose values remain []byte.
+	binaryTypeNames = map[string]bool{
+		"BINARY":     true,
+		"VARBINARY":  true,
+		"BLOB":       true,
+		"TINYBLOB":   true,
+		"MEDIUMBLOB": true,
+		"LONGBLOB":   true,
+		"BYTEA":      true,
+		"BIT":        true,
+		"GEOMETRY":   true,
+	}
+
+	// nullableKinds maps the nullable scan types to the kind of their value.
+	nullableKinds = map[reflect.Type]reflect.Kind{
+		reflect.TypeOf(sql.NullInt64{}):   reflect.Int64,
+		reflect.TypeOf(sql.NullInt32{}):   reflect.Int64,
+		reflect.TypeOf(sql.NullInt16{}):   reflect.Int64,
+		reflect.TypeOf(sql.NullByte{}):    reflect.Uint64,
+		reflect.TypeOf(sql.NullFloat64{}): reflect.Float64,
+		reflect.TypeOf(sql.NullBool{}):    reflect.Bool,
+	}
+)
+
+// convertDynamicValue converts driver values into sensible go types. Some drivers (eg. mysql)
+// return []byte for text and even numeric columns, which is converted to string or a number based on
+// the column type. Values of binary or unknown columns remain []byte.
+func convertDynamicValue(column *sql.ColumnType, value any) any {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	// without a known type name (eg. sqlite expressions) there is no reason to assume text
+	typeName := strings.ToUpper(column.DatabaseTypeName())
+	if typeName == "" || binaryTypeNames[typeName] {
+		return b
+	}
+
+	s := string(b)
+
+	switch scanKind(column) {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return s
+}
+
+func scanKind(column *sql.ColumnType) reflect.Kind {
+	scanType := column.ScanType()
+	if scanType == nil {
+		return reflect.Invalid
+	}
+
+	if kind, ok := nullableKinds[scanType]; ok {
+		return kind
+	}
+
+	return scanType.Kind()
+}
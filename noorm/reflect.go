@@ -1,9 +1,11 @@
 package noorm
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -24,18 +26,77 @@ func indirectInterface(v reflect.Value) reflect.Value {
 	return v
 }
 
-type fieldLookupMap map[string][]int // name -> path of field indices
+// fieldOptions are the options of a field as declared in the struct tag after the name.
+// Example: `db:"id,pk,auto"`.
+type fieldOptions struct {
+	// pk marks a field as part of the primary key.
+	pk bool
+	// auto marks a field as generated by the database. It is skipped on inserts and updates.
+	auto bool
+	// readonly marks a field as never written. It is skipped on inserts and updates.
+	readonly bool
+	// omitempty skips a field on inserts and updates, if it has a zero value.
+	omitempty bool
+	// optional marks a field as not required to be populated in strict mode (see StrictFields).
+	optional bool
+	// prefixed marks a nested struct field, whose fields are mapped to columns starting with
+	// prefix. Example: `db:"author,prefix=author_"`.
+	prefixed bool
+	prefix   string
+}
+
+// writable reports whether a field may be written by generated inserts and updates.
+func (o fieldOptions) writable() bool {
+	return !o.auto && !o.readonly
+}
+
+type fieldInfo struct {
+	index []int // path of field indices
+	// aliasOf is the column name of the field, if this is the dotted alias of a field within a
+	// prefixed struct (eg. `author.id` for `author_id`).
+	aliasOf string
+	// elem is the path of field indices within the elements of the slice at index, if the field
+	// is within a prefixed slice of structs (a collection).
+	elem []int
+	fieldOptions
+}
+
+type fieldLookupMap map[string]fieldInfo // name -> field
+
+// columns returns the sorted names of all fields, which satisfy the filter.
+// A nil filter accepts all fields. Aliases and fields of collections are never returned.
+func (lookup fieldLookupMap) columns(filter func(fieldInfo) bool) []string {
+	columns := make([]string, 0, len(lookup))
+	for name, field := range lookup {
+		if field.aliasOf == "" && field.elem == nil && (filter == nil || filter(field)) {
+			columns = append(columns, name)
+		}
+	}
 
-func buildFieldLookupMapOfType(t reflect.Type) (fieldLookupMap, error) {
-	lookup := make(fieldLookupMap)
-	return lookup, analyzeStructFields(lookup, nil, t)
+	sort.Strings(columns)
+	return columns
 }
 
 func buildFieldLookupMap[T Struct]() (fieldLookupMap, error) {
-	return buildFieldLookupMapOfType(typeOfGeneric[T]())
+	return defaultNaming.lookup(typeOfGeneric[T]())
+}
+
+// structPath is the position of a nested struct within the analyzed struct.
+type structPath struct {
+	index  []int  // path of field indices
+	column string // prefix of column names
+	alias  string // prefix of dotted aliases
+	// parents are the enclosing struct types to detect recursion.
+	parents []reflect.Type
+	// collection is the path of field indices to the enclosing collection. If set, index is the
+	// path within the elements of the collection.
+	collection []int
 }
 
-func analyzeStructFields(lookup fieldLookupMap, prefix []int, t reflect.Type) error {
+// analyzeStructFields adds the fields of t to the lookup. Fields without explicit name in their tag
+// are named by the mapper.
+func analyzeStructFields(lookup fieldLookupMap, path structPath, t reflect.Type,
+	mapper NameMapper) error {
 	if t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
@@ -44,6 +105,12 @@ func analyzeStructFields(lookup fieldLookupMap, prefix []int, t reflect.Type) er
 		return fmt.Errorf("%w: cannot traverse %q, expected a struct", ErrInvalidTargetType, t)
 	}
 
+	for _, parent := range path.parents {
+		if parent == t {
+			return fmt.Errorf("%w: cannot traverse recursive struct %q", ErrInvalidTargetType, t)
+		}
+	}
+
 	for i := t.NumField() - 1; i >= 0; i-- {
 		field := t.Field(i)
 
@@ -52,29 +119,96 @@ func analyzeStructFields(lookup fieldLookupMap, prefix []int, t reflect.Type) er
 			continue
 		}
 
-		index := appendFieldIndex(prefix, i)
+		name, options, ignore := parseFieldTag(field)
+		if ignore {
+			continue
+		}
 
-		if field.Anonymous {
-			if err := analyzeStructFields(lookup, index, field.Type); err != nil {
-				return err
-			}
-		} else {
-			name := fieldName(field)
+		if name == "" {
+			name = mapper(field)
+		}
+
+		if !field.Anonymous || options.prefixed {
 			if !isValidFieldName(name) {
 				return fmt.Errorf("%w: invalid field name %q", ErrInvalidTargetType, name)
 			}
+		}
+
+		index := appendFieldIndex(path.index, i)
+
+		if field.Anonymous || options.prefixed {
+			nested := structPath{
+				index:      index,
+				column:     path.column,
+				alias:      path.alias,
+				parents:    append(path.parents[:len(path.parents):len(path.parents)], t),
+				collection: path.collection,
+			}
+
+			fieldType := field.Type
+			if options.prefixed && fieldType.Kind() == reflect.Slice {
+				if path.collection != nil {
+					return fmt.Errorf("%w: cannot traverse collection %q within a collection",
+						ErrInvalidTargetType, name)
+				}
 
-			if _, ok := lookup[name]; ok {
-				return fmt.Errorf("%w: duplicate struct field %q", ErrInvalidTargetType, name)
+				fieldType = fieldType.Elem()
+				if fieldType.Kind() != reflect.Struct {
+					return fmt.Errorf("%w: cannot traverse collection %q, expected a slice of structs",
+						ErrInvalidTargetType, name)
+				}
+
+				nested.index = nil
+				nested.collection = index
 			}
 
-			lookup[name] = index
+			if options.prefixed {
+				if !isValidFieldName(options.prefix) {
+					return fmt.Errorf("%w: invalid prefix %q", ErrInvalidTargetType, options.prefix)
+				}
+
+				nested.column += options.prefix
+				nested.alias += name + "."
+			}
+
+			if err := analyzeStructFields(lookup, nested, fieldType, mapper); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		info := fieldInfo{index: index, fieldOptions: options}
+		if path.collection != nil {
+			info.index, info.elem = path.collection, index
+		}
+
+		column := path.column + name
+		if err := lookup.add(column, info); err != nil {
+			return err
+		}
+
+		if alias := path.alias + name; path.alias != "" && alias != column {
+			info.aliasOf = column
+
+			if err := lookup.add(alias, info); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+func (lookup fieldLookupMap) add(name string, field fieldInfo) error {
+	if _, ok := lookup[name]; ok {
+		return fmt.Errorf("%w: duplicate struct field %q", ErrInvalidTargetType, name)
+	}
+
+	lookup[name] = field
+	return nil
+}
+
 func appendFieldIndex(prefix []int, i int) []int {
 	path := make([]int, len(prefix)+1)
 	copy(path, prefix)
@@ -92,16 +226,45 @@ func isValidFieldName(fieldName string) bool {
 	return true
 }
 
-func fieldName(field reflect.StructField) string {
-	if tag, ok := field.Tag.Lookup("db"); ok {
-		if comma := strings.IndexByte(tag, ','); comma > -1 {
-			tag = tag[:comma]
+// parseFieldTag parses the `db` tag of a field into its name and options.
+// Unknown options are ignored. A field tagged `db:"-"` should be ignored entirely. The name is
+// empty, if the tag does not declare one (see NameMapper).
+// The `prefix=...` option takes a value and marks a nested struct to be traversed.
+func parseFieldTag(field reflect.StructField) (name string, options fieldOptions, ignore bool) {
+	tag := field.Tag.Get("db")
+	if tag == "-" {
+		return "", options, true
+	}
+
+	name, tag, _ = strings.Cut(tag, ",")
+
+	for tag != "" {
+		var option string
+		option, tag, _ = strings.Cut(tag, ",")
+
+		option = strings.TrimSpace(option)
+
+		if strings.HasPrefix(option, "prefix=") {
+			options.prefixed = true
+			options.prefix = strings.TrimPrefix(option, "prefix=")
+			continue
 		}
 
-		return tag
+		switch option {
+		case "pk":
+			options.pk = true
+		case "auto":
+			options.auto = true
+		case "readonly":
+			options.readonly = true
+		case "omitempty":
+			options.omitempty = true
+		case "optional":
+			options.optional = true
+		}
 	}
 
-	return field.Name
+	return name, options, false
 }
 
 func initializeFieldPath(v reflect.Value, index []int) {
@@ -117,19 +280,157 @@ func initializeFieldPath(v reflect.Value, index []int) {
 	}
 }
 
-func buildScanTargetSlice(lookup fieldLookupMap, columns []string, v reflect.Value) ([]any, error) {
-	targetSlice := make([]any, len(columns))
+// scanPlan is the precomputed mapping of the columns of a result set to the fields of a struct.
+type scanPlan struct {
+	columns []scanColumn
+	// nested is set, if any column is scanned into a holder.
+	nested bool
+}
+
+type scanColumn struct {
+	// index is the path of field indices, nil if the column is discarded.
+	index []int
+	// elem is the path of field indices within the element of a collection (see fieldInfo).
+	elem []int
+	// holder is the type of the nullable holder (a pointer to the field type), if the field is
+	// within a nested pointer struct or a collection. The nested struct or element is only
+	// allocated, if a holder is non-nil.
+	holder reflect.Type
+}
 
+// buildScanPlan returns the scan plan of columns into the struct type t with the lookup map of t.
+func buildScanPlan(t reflect.Type, lookup fieldLookupMap, columns []string) *scanPlan {
+	plan := scanPlan{columns: make([]scanColumn, len(columns))}
 	for i, column := range columns {
-		if index, ok := lookup[column]; ok {
-			initializeFieldPath(v, index)
-			field := v.FieldByIndex(index)
-			targetSlice[i] = field.Addr().Interface()
-		} else {
-			var discard any
-			targetSlice[i] = &discard
+		field, ok := lookup[column]
+		if !ok {
+			continue
+		}
+
+		plan.columns[i].index = field.index
+
+		if field.elem != nil {
+			collectionType, _ := fieldTypeByIndex(t, field.index)
+			fieldType, _ := fieldTypeByIndex(collectionType.Elem(), field.elem)
+
+			plan.columns[i].elem = field.elem
+			plan.columns[i].holder = reflect.PointerTo(fieldType)
+			plan.nested = true
+		} else if fieldType, indirect := fieldTypeByIndex(t, field.index); indirect {
+			plan.columns[i].holder = reflect.PointerTo(fieldType)
+			plan.nested = true
 		}
 	}
 
-	return targetSlice, nil
+	return &plan
+}
+
+// fieldTypeByIndex returns the type of a nested field and whether its path traverses a pointer.
+func fieldTypeByIndex(t reflect.Type, index []int) (fieldType reflect.Type, indirect bool) {
+	for _, i := range index {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+			indirect = true
+		}
+
+		t = t.Field(i).Type
+	}
+
+	return t, indirect
+}
+
+// scan scans the current row into the struct v according to the plan.
+func (p *scanPlan) scan(rows *sql.Rows, v reflect.Value) error {
+	targetSlice, holders := p.targets(v)
+
+	if err := rows.Scan(targetSlice...); err != nil {
+		return err
+	}
+
+	p.assignHolders(v, holders)
+	return nil
+}
+
+// targets returns the scan targets of the plan within the struct v. Columns of nested pointer
+// structs are scanned into the returned holders instead (see assignHolders).
+func (p *scanPlan) targets(v reflect.Value) (targetSlice []any, holders []reflect.Value) {
+	targetSlice = make([]any, len(p.columns))
+
+	if p.nested {
+		holders = make([]reflect.Value, len(p.columns))
+	}
+
+	for i, column := range p.columns {
+		switch {
+		case column.index == nil:
+			targetSlice[i] = discardScanner{}
+
+		case column.holder != nil:
+			holders[i] = reflect.New(column.holder)
+			targetSlice[i] = holders[i].Interface()
+
+		default:
+			initializeFieldPath(v, column.index)
+			targetSlice[i] = v.FieldByIndex(column.index).Addr().Interface()
+		}
+	}
+
+	return targetSlice, holders
+}
+
+// assignHolders assigns the non-null holders to their fields, allocating the nested structs of
+// those fields on the way. Nested structs without any non-null column remain nil.
+func (p *scanPlan) assignHolders(v reflect.Value, holders []reflect.Value) {
+	for i, holder := range holders {
+		if !holder.IsValid() || holder.Elem().IsNil() {
+			continue
+		}
+
+		column := p.columns[i]
+		target, index := v, column.index
+
+		if column.elem != nil {
+			target, index = collectionElem(v, column.index), column.elem
+		}
+
+		initializeFieldPath(target, index[:len(index)-1])
+		target.FieldByIndex(index).Set(holder.Elem().Elem())
+	}
+}
+
+// collectionElem returns the first element of the collection at index, which is appended if the
+// collection is empty. Every row is scanned into a new value, so collections have one element at
+// most, until they are merged (see QueryAggregate).
+func collectionElem(v reflect.Value, index []int) reflect.Value {
+	initializeFieldPath(v, index)
+
+	collection := v.FieldByIndex(index)
+	if collection.Len() == 0 {
+		collection.Set(reflect.Append(collection, reflect.Zero(collection.Type().Elem())))
+	}
+
+	return collection.Index(0)
+}
+
+// discardScanner discards the value of unmapped columns.
+type discardScanner struct{}
+
+func (discardScanner) Scan(any) error {
+	return nil
+}
+
+// setInt64 assigns an integer to a field of any integer type.
+func setInt64(field reflect.Value, value int64) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(value))
+
+	default:
+		return fmt.Errorf("%w: cannot assign integer to %q", ErrInvalidTargetType, field.Type())
+	}
+
+	return nil
 }
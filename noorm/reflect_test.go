@@ -24,13 +24,114 @@ func TestBuildFieldLookupMap(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, index, 4)
 	assert.Equal(t, fieldLookupMap{
-		"Field1":  []int{0},
-		"field_2": []int{1},
-		"field_3": []int{2},
-		"Field4":  []int{3, 0},
+		"Field1":  {index: []int{0}},
+		"field_2": {index: []int{1}},
+		"field_3": {index: []int{2}},
+		"Field4":  {index: []int{3, 0}},
 	}, index)
 }
 
+func TestBuildFieldLookupMapOptions(t *testing.T) {
+	type TestStruct struct {
+		ID       int    `db:"id,pk,auto"`
+		Name     string `db:"name,omitempty"`
+		Computed string `db:"computed,readonly"`
+		Ignored  string `db:"-"`
+		Default  string `db:",pk"`
+		Nickname string `db:"nickname,optional"`
+	}
+
+	index, err := buildFieldLookupMap[TestStruct]()
+	require.NoError(t, err)
+	assert.Equal(t, fieldLookupMap{
+		"id":       {index: []int{0}, fieldOptions: fieldOptions{pk: true, auto: true}},
+		"name":     {index: []int{1}, fieldOptions: fieldOptions{omitempty: true}},
+		"computed": {index: []int{2}, fieldOptions: fieldOptions{readonly: true}},
+		"Default":  {index: []int{4}, fieldOptions: fieldOptions{pk: true}},
+		"nickname": {index: []int{5}, fieldOptions: fieldOptions{optional: true}},
+	}, index)
+}
+
+func TestBuildFieldLookupMapPrefix(t *testing.T) {
+	type Geo struct {
+		Lat float64 `db:"lat"`
+	}
+
+	type User struct {
+		ID   int    `db:"id,pk"`
+		Name string `db:"name"`
+		Geo  `db:"geo,prefix=geo_"`
+	}
+
+	type TestStruct struct {
+		ID     int   `db:"id,pk"`
+		Author *User `db:"author,prefix=author_"`
+	}
+
+	index, err := buildFieldLookupMap[TestStruct]()
+	require.NoError(t, err)
+	assert.Equal(t, fieldLookupMap{
+		"id":             {index: []int{0}, fieldOptions: fieldOptions{pk: true}},
+		"author_id":      {index: []int{1, 0}, fieldOptions: fieldOptions{pk: true}},
+		"author.id":      {index: []int{1, 0}, aliasOf: "author_id", fieldOptions: fieldOptions{pk: true}},
+		"author_name":    {index: []int{1, 1}},
+		"author.name":    {index: []int{1, 1}, aliasOf: "author_name"},
+		"author_geo_lat": {index: []int{1, 2, 0}},
+		"author.geo.lat": {index: []int{1, 2, 0}, aliasOf: "author_geo_lat"},
+	}, index)
+	assert.Equal(t, []string{"author_geo_lat", "author_id", "author_name", "id"}, index.columns(nil))
+
+	type UnprefixedStruct struct {
+		ID     int  `db:"id"`
+		Author User `db:"author,prefix="`
+	}
+
+	_, err = buildFieldLookupMap[UnprefixedStruct]()
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+
+	type InvalidPrefixStruct struct {
+		Author User `db:"author,prefix=a b"`
+	}
+
+	_, err = buildFieldLookupMap[InvalidPrefixStruct]()
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+
+	type RecursiveStruct struct {
+		ID     int              `db:"id"`
+		Parent *RecursiveStruct `db:"parent,prefix=parent_"`
+	}
+
+	_, err = buildFieldLookupMap[RecursiveStruct]()
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+}
+
+func TestBuildFieldLookupMapCollection(t *testing.T) {
+	type Post struct {
+		ID int `db:"id,pk"`
+	}
+
+	type TestStruct struct {
+		ID    int    `db:"id,pk"`
+		Posts []Post `db:"posts,prefix=post_"`
+	}
+
+	index, err := buildFieldLookupMap[TestStruct]()
+	require.NoError(t, err)
+	assert.Equal(t, fieldLookupMap{
+		"id":       {index: []int{0}, fieldOptions: fieldOptions{pk: true}},
+		"post_id":  {index: []int{1}, elem: []int{0}, fieldOptions: fieldOptions{pk: true}},
+		"posts.id": {index: []int{1}, elem: []int{0}, aliasOf: "post_id", fieldOptions: fieldOptions{pk: true}},
+	}, index)
+	assert.Equal(t, []string{"id"}, index.columns(nil))
+
+	type NestedCollectionStruct struct {
+		Users []TestStruct `db:"users,prefix=user_"`
+	}
+
+	_, err = buildFieldLookupMap[NestedCollectionStruct]()
+	assert.ErrorIs(t, err, ErrInvalidTargetType)
+}
+
 func TestInitializeFieldPath(t *testing.T) {
 	type TestStruct struct {
 		Field1 struct {
@@ -49,3 +150,147 @@ func TestInitializeFieldPath(t *testing.T) {
 	require.NotNil(t, s.Field1.Field2)
 	require.NotNil(t, s.Field1.Field2.Field3)
 }
+
+func TestBuildScanPlan(t *testing.T) {
+	type TestStruct struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	target := reflect.TypeOf(TestStruct{})
+	lookup, err := defaultNaming.lookup(target)
+	require.NoError(t, err)
+
+	cached, err := defaultNaming.lookup(reflect.PointerTo(target))
+	require.NoError(t, err)
+	assert.Equal(t, reflect.ValueOf(lookup).Pointer(), reflect.ValueOf(cached).Pointer())
+
+	plan := defaultNaming.scanPlan(target, lookup, []string{"name", "unknown", "id"})
+	assert.Equal(t, []scanColumn{{index: []int{1}}, {}, {index: []int{0}}}, plan.columns)
+	assert.Same(t, plan, defaultNaming.scanPlan(target, lookup, []string{"name", "unknown", "id"}))
+
+	var s TestStruct
+	targetSlice, holders := plan.targets(reflect.ValueOf(&s).Elem())
+	assert.Equal(t, []any{&s.Name, discardScanner{}, &s.ID}, targetSlice)
+	assert.Nil(t, holders)
+}
+
+func TestScanPlanNested(t *testing.T) {
+	type Geo struct {
+		Lat float64 `db:"lat"`
+	}
+
+	type Address struct {
+		Street string `db:"street"`
+		*Geo
+	}
+
+	type TestStruct struct {
+		ID int `db:"id"`
+		*Address
+	}
+
+	target := reflect.TypeOf(TestStruct{})
+	lookup, err := defaultNaming.lookup(target)
+	require.NoError(t, err)
+
+	plan := buildScanPlan(target, lookup, []string{"id", "street", "lat"})
+	assert.True(t, plan.nested)
+	assert.Equal(t, []scanColumn{
+		{index: []int{0}},
+		{index: []int{1, 0}, holder: reflect.TypeOf((*string)(nil))},
+		{index: []int{1, 1, 0}, holder: reflect.TypeOf((*float64)(nil))},
+	}, plan.columns)
+
+	var s TestStruct
+	v := reflect.ValueOf(&s).Elem()
+
+	_, holders := plan.targets(v)
+	plan.assignHolders(v, holders)
+	assert.Nil(t, s.Address)
+
+	_, holders = plan.targets(v)
+	holders[1].Elem().Set(reflect.ValueOf(new(string)))
+	plan.assignHolders(v, holders)
+	require.NotNil(t, s.Address)
+	assert.Nil(t, s.Address.Geo)
+}
+
+type benchmarkStruct struct {
+	ID        int64  `db:"id,pk,auto"`
+	Name      string `db:"name"`
+	Email     string `db:"email"`
+	CreatedAt string `db:"created_at"`
+	benchmarkEmbeddedStruct
+}
+
+type benchmarkEmbeddedStruct struct {
+	Street string `db:"street"`
+	City   string `db:"city"`
+}
+
+var benchmarkColumns = []string{"id", "name", "email", "created_at", "street", "city", "unknown"}
+
+func BenchmarkBuildFieldLookupMap(b *testing.B) {
+	target := reflect.TypeOf(benchmarkStruct{})
+
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			lookup := make(fieldLookupMap)
+			if err := analyzeStructFields(lookup, structPath{}, target, IdentityNames); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := defaultNaming.lookup(target); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkScanTargets(b *testing.B) {
+	target := reflect.TypeOf(benchmarkStruct{})
+
+	lookup, err := defaultNaming.lookup(target)
+	require.NoError(b, err)
+
+	b.Run("Lookup", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			var s benchmarkStruct
+			v := reflect.ValueOf(&s).Elem()
+			targetSlice := make([]any, len(benchmarkColumns))
+
+			// resolve every column per row, as done before scan plans were introduced
+			for j, column := range benchmarkColumns {
+				if field, ok := lookup[column]; ok {
+					initializeFieldPath(v, field.index)
+					targetSlice[j] = v.FieldByIndex(field.index).Addr().Interface()
+				} else {
+					var discard any
+					targetSlice[j] = &discard
+				}
+			}
+		}
+	})
+
+	b.Run("Plan", func(b *testing.B) {
+		b.ReportAllocs()
+
+		plan := buildScanPlan(target, lookup, benchmarkColumns)
+
+		for i := 0; i < b.N; i++ {
+			var s benchmarkStruct
+			plan.targets(reflect.ValueOf(&s).Elem())
+		}
+	})
+}
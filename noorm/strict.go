@@ -0,0 +1,126 @@
+package noorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrStrictMapping = errors.New("noorm: strict mapping")
+)
+
+type ctxStrictKey struct{}
+
+// StrictMode controls, whether the mapping of columns to struct fields is checked when scanning
+// rows into structs. The checks are performed once per result set, before the first row is scanned.
+type StrictMode uint8
+
+const (
+	// StrictColumns rejects result sets with columns, which are not mapped to any field.
+	StrictColumns StrictMode = 1 << iota
+	// StrictFields rejects result sets, which do not populate every field. Fields tagged with
+	// the `optional` option (eg. `db:"name,optional"`) are exempt.
+	StrictFields
+
+	// Strict enables all checks.
+	Strict = StrictColumns | StrictFields
+)
+
+// SetStrict sets the strict mode used by all queries of the database.
+// It can be overridden for single calls using WithStrict. The default is no checks at all.
+func (db *Database) SetStrict(mode StrictMode) {
+	db.strict.Store(uint32(mode))
+}
+
+// WithStrict returns a context, which overrides the strict mode of the database for all queries
+// using the context.
+func WithStrict(ctx context.Context, mode StrictMode) context.Context {
+	return context.WithValue(ctx, ctxStrictKey{}, mode)
+}
+
+// strictModeFrom returns the strict mode of the context or the database in the context.
+func strictModeFrom(ctx context.Context) StrictMode {
+	if mode, ok := ctx.Value(ctxStrictKey{}).(StrictMode); ok {
+		return mode
+	}
+
+	if tx, ok := ctx.Value(ctxTransactionKey{}).(*transaction); ok {
+		return StrictMode(tx.db.strict.Load())
+	}
+
+	if db, ok := ctx.Value(ctxDatabaseKey{}).(*Database); ok {
+		return StrictMode(db.strict.Load())
+	}
+
+	return 0
+}
+
+// strictMappingError lists the columns and fields violating the strict mode of a mapping.
+type strictMappingError struct {
+	target  reflect.Type
+	columns []string
+	fields  []string
+}
+
+func (e *strictMappingError) Error() string {
+	var problems []string
+
+	if len(e.columns) > 0 {
+		problems = append(problems, fmt.Sprintf("unmapped columns [%s]", strings.Join(e.columns, ", ")))
+	}
+
+	if len(e.fields) > 0 {
+		problems = append(problems, fmt.Sprintf("unfilled fields [%s]", strings.Join(e.fields, ", ")))
+	}
+
+	return fmt.Sprintf("noorm: strict mapping of %q: %s", e.target, strings.Join(problems, " and "))
+}
+
+func (e *strictMappingError) Unwrap() error {
+	return ErrStrictMapping
+}
+
+// checkStrictMapping checks the mapping of columns to the fields of a lookup according to mode.
+func checkStrictMapping(t reflect.Type, lookup fieldLookupMap, columns []string, mode StrictMode) error {
+	if mode == 0 {
+		return nil
+	}
+
+	var unmapped []string
+
+	mapped := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if field, ok := lookup[column]; ok {
+			if field.aliasOf != "" {
+				column = field.aliasOf
+			}
+
+			mapped[column] = true
+		} else if mode&StrictColumns != 0 {
+			unmapped = append(unmapped, column)
+		}
+	}
+
+	var unfilled []string
+
+	if mode&StrictFields != 0 {
+		for _, name := range lookup.columns(nil) {
+			if !mapped[name] && !lookup[name].optional {
+				unfilled = append(unfilled, name)
+			}
+		}
+	}
+
+	if len(unmapped) > 0 || len(unfilled) > 0 {
+		return &strictMappingError{
+			target:  t,
+			columns: unmapped,
+			fields:  unfilled,
+		}
+	}
+
+	return nil
+}
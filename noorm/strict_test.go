@@ -0,0 +1,45 @@
+package noorm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStrictMapping(t *testing.T) {
+	type testStruct struct {
+		ID       int    `db:"id"`
+		Name     string `db:"name"`
+		Nickname string `db:"nickname,optional"`
+	}
+
+	target := reflect.TypeOf(testStruct{})
+	lookup, err := defaultNaming.lookup(target)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		columns []string
+		mode    StrictMode
+		err     string
+	}{
+		{columns: []string{"id", "extra"}, mode: 0},
+		{columns: []string{"id", "name"}, mode: Strict},
+		{columns: []string{"id", "name", "nickname"}, mode: Strict},
+		{columns: []string{"id", "extra"}, mode: StrictFields,
+			err: `noorm: strict mapping of "noorm.testStruct": unfilled fields [name]`},
+		{columns: []string{"id", "extra"}, mode: StrictColumns,
+			err: `noorm: strict mapping of "noorm.testStruct": unmapped columns [extra]`},
+		{columns: []string{"extra", "other"}, mode: Strict,
+			err: `noorm: strict mapping of "noorm.testStruct": unmapped columns [extra, other] and unfilled fields [id, name]`},
+	} {
+		err := checkStrictMapping(target, lookup, tc.columns, tc.mode)
+
+		if tc.err == "" {
+			assert.NoError(t, err, "columns=%v", tc.columns)
+		} else {
+			assert.ErrorIs(t, err, ErrStrictMapping, "columns=%v", tc.columns)
+			assert.EqualError(t, err, tc.err, "columns=%v", tc.columns)
+		}
+	}
+}
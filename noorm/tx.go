@@ -0,0 +1,248 @@
+package noorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// DefaultRetryPolicy is used by RunInTx, when no RetryPolicy is provided.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	Retryable:   IsRetryable,
+}
+
+// RetryPolicy decides whether and when RunInTx retries a failed transaction.
+// Zero fields are filled with the values of DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry. It doubles with every further retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts.
+	MaxBackoff time.Duration
+	// Retryable reports whether a failed attempt should be retried.
+	Retryable func(error) bool
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.MinBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	// add jitter, so that conflicting transactions do not retry in lockstep
+	half := int64(backoff / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// TxOptions configures the transactions started by RunInTx.
+type TxOptions struct {
+	// Isolation is the isolation level of the transaction.
+	Isolation sql.IsolationLevel
+	// ReadOnly starts a read-only transaction.
+	ReadOnly bool
+	// Retry is the policy to retry failed transactions. If nil, DefaultRetryPolicy is used.
+	Retry *RetryPolicy
+}
+
+func fillTxDefaults(opts *TxOptions) (*sql.TxOptions, RetryPolicy) {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	policy := DefaultRetryPolicy
+	if opts.Retry != nil {
+		policy = *opts.Retry
+
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+		}
+
+		if policy.MinBackoff <= 0 {
+			policy.MinBackoff = DefaultRetryPolicy.MinBackoff
+		}
+
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+		}
+
+		if policy.Retryable == nil {
+			policy.Retryable = DefaultRetryPolicy.Retryable
+		}
+	}
+
+	txOpts := sql.TxOptions{
+		Isolation: opts.Isolation,
+		ReadOnly:  opts.ReadOnly,
+	}
+
+	return &txOpts, policy
+}
+
+// RunInTx calls fn within a transaction (see Begin), which is committed if fn returns nil and
+// rolled back otherwise. If fn panics, the transaction is rolled back and the panic is propagated.
+// When the transaction fails with an error considered retryable by the RetryPolicy, the whole
+// transaction including fn is retried after a backoff. Therefore fn must be safe to be called
+// multiple times.
+// If the context already carries a transaction, fn is called exactly once within a savepoint,
+// because only the outermost transaction can be retried meaningfully.
+// RunInTx expects a Database to be present in the context (see WithDatabase).
+func RunInTx(ctx context.Context, opts *TxOptions, fn func(context.Context) error) error {
+	txOpts, policy := fillTxDefaults(opts)
+
+	if inTransaction(ctx) {
+		return runInTx(ctx, nil, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runInTx(ctx, txOpts, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+
+		case <-timer.C:
+		}
+	}
+}
+
+func runInTx(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+	ctx, tx, err := Begin(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AfterCommit registers a callback, which is called once the transaction of the context has been
+// committed. Callbacks of savepoints are deferred until the outermost transaction is committed and
+// discarded, if the savepoint is rolled back. Callbacks are called in registration order.
+// If the context does not carry a transaction, the callback is called immediately.
+func AfterCommit(ctx context.Context, callback func()) {
+	tx, ok := ctx.Value(ctxTransactionKey{}).(*transaction)
+	if !ok {
+		callback()
+		return
+	}
+
+	tx.hooks.register(callback, nil)
+}
+
+// AfterRollback registers a callback, which is called once the transaction of the context has been
+// rolled back, either directly, by rolling back an enclosing transaction or by a failed commit.
+// Callbacks are called in registration order. If the context does not carry a transaction, the callback is never called.
+func AfterRollback(ctx context.Context, callback func()) {
+	tx, ok := ctx.Value(ctxTransactionKey{}).(*transaction)
+	if !ok {
+		return
+	}
+
+	tx.hooks.register(nil, callback)
+}
+
+var (
+	// retryableSQLStates are the sqlstate codes of serialization failures and deadlocks.
+	retryableSQLStates = map[string]bool{
+		"40001": true, // serialization_failure
+		"40P01": true, // deadlock_detected (postgres)
+	}
+
+	// retryableErrorCodes are driver specific error codes, which are not reported as sqlstate.
+	// They are keyed by the package path of the driver's error type, so that error types of other
+	// packages with a field of the same name are not mistaken for driver errors.
+	retryableErrorCodes = map[string]retryableErrorCode{
+		"github.com/go-sql-driver/mysql": {field: "Number", codes: map[int64]bool{
+			1213: true, // ER_LOCK_DEADLOCK
+		}},
+		"github.com/mattn/go-sqlite3": {field: "Code", codes: map[int64]bool{
+			5: true, // SQLITE_BUSY
+		}},
+	}
+)
+
+// retryableErrorCode is the integer field of a driver's error type and its retryable values.
+type retryableErrorCode struct {
+	field string
+	codes map[int64]bool
+}
+
+// IsRetryable reports whether an error is a transient failure of a transaction, which may succeed
+// when the transaction is retried. These are serialization failures and deadlocks in PostgreSQL
+// (sqlstate 40001 and 40P01), deadlocks in MySQL (error 1213) and busy databases in SQLite
+// (SQLITE_BUSY).
+// IsRetryable does not depend on any driver. Instead errors are inspected for a `SQLState() string`
+// method or the integer fields `Number` and `Code` of the error types of the drivers
+// github.com/go-sql-driver/mysql and github.com/mattn/go-sqlite3.
+func IsRetryable(err error) bool {
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) && retryableSQLStates[stateErr.SQLState()] {
+		return true
+	}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if hasRetryableErrorCode(err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasRetryableErrorCode(err error) bool {
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	code, ok := retryableErrorCodes[v.Type().PkgPath()]
+	if !ok {
+		return false
+	}
+
+	structField, ok := v.Type().FieldByName(code.field)
+	if !ok || len(structField.Index) > 1 {
+		// skip promoted fields, which may be nil pointers to embedded structs
+		return false
+	}
+
+	field := v.Field(structField.Index[0])
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return code.codes[field.Int()]
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return code.codes[int64(field.Uint())]
+	}
+
+	return false
+}
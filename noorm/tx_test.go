@@ -0,0 +1,258 @@
+package noorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSQLStateError string
+
+func (e testSQLStateError) Error() string {
+	return "sqlstate " + string(e)
+}
+
+func (e testSQLStateError) SQLState() string {
+	return string(e)
+}
+
+// testMysqlError and testSqliteError look like the error types of the drivers, but must not be
+// mistaken for them.
+type testMysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *testMysqlError) Error() string {
+	return e.Message
+}
+
+type testSqliteError struct {
+	Code int
+}
+
+func (e testSqliteError) Error() string {
+	return "sqlite"
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{err: testSQLStateError("40001"), retryable: true},
+		{err: testSQLStateError("40P01"), retryable: true},
+		{err: testSQLStateError("23505"), retryable: false},
+		{err: &mysql.MySQLError{Number: 1213}, retryable: true},
+		{err: &mysql.MySQLError{Number: 1062}, retryable: false},
+		{err: sqlite3.Error{Code: sqlite3.ErrBusy}, retryable: true},
+		{err: sqlite3.Error{Code: sqlite3.ErrConstraint}, retryable: false},
+		{err: fmt.Errorf("wrapped: %w", sqlite3.Error{Code: sqlite3.ErrBusy}), retryable: true},
+		{err: &testMysqlError{Number: 1213}, retryable: false},
+		{err: testSqliteError{Code: 5}, retryable: false},
+		{err: fmt.Errorf("wrapped: %w", testSqliteError{Code: 5}), retryable: false},
+		{err: errors.New("other"), retryable: false},
+		{err: nil, retryable: false},
+	} {
+		assert.Equal(t, tc.retryable, IsRetryable(tc.err), "err=%v", tc.err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 30 * time.Millisecond,
+	}
+
+	for attempt, maximum := range []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		30 * time.Millisecond,
+		30 * time.Millisecond,
+	} {
+		backoff := policy.backoff(attempt + 1)
+		assert.GreaterOrEqual(t, backoff, maximum/2)
+		assert.LessOrEqual(t, backoff, maximum)
+	}
+}
+
+func openTestFileDatabase(t *testing.T, path string) *Database {
+	db, err := Open("sqlite3", "file:"+path+"?_busy_timeout=0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRunInTxRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db := openTestFileDatabase(t, path)
+	ctx := WithDatabase(context.Background(), db)
+
+	_, err := Exec(ctx, SQL{Query: `create table "fruits" ( "name" varchar not null ) ;`})
+	require.NoError(t, err)
+
+	blockingCtx, blockingTx, err := Begin(WithDatabase(context.Background(), openTestFileDatabase(t, path)), nil)
+	require.NoError(t, err)
+	defer blockingTx.Rollback()
+
+	_, err = Exec(blockingCtx, SQL{Query: `insert into "fruits" ( "name" ) values ( 'Apple' ) ;`})
+	require.NoError(t, err)
+
+	var attempts int
+
+	opts := TxOptions{
+		Retry: &RetryPolicy{MinBackoff: time.Millisecond},
+	}
+
+	err = RunInTx(ctx, &opts, func(ctx context.Context) error {
+		attempts++
+
+		_, err := Exec(ctx, SQL{Query: `insert into "fruits" ( "name" ) values ( 'Banana' ) ;`})
+		if attempts == 1 {
+			assert.True(t, IsRetryable(err), "err=%v", err)
+			assert.NoError(t, blockingTx.Rollback())
+		}
+
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRunInTx(t *testing.T) {
+	db, err := Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+	ctx := WithDatabase(context.Background(), db)
+
+	_, err = Exec(ctx, SQL{Query: `create table "fruits" ( "name" varchar not null ) ;`})
+	require.NoError(t, err)
+
+	insert := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			_, err := Exec(ctx, SQL{
+				Query: `insert into "fruits" ( "name" ) values ( @0 ) ;`,
+				Args:  Positional(name),
+			})
+			return err
+		}
+	}
+
+	errFailed := errors.New("failed")
+	attempts := 0
+
+	require.NoError(t, RunInTx(ctx, nil, insert("Apple")))
+
+	assert.ErrorIs(t, RunInTx(ctx, nil, func(ctx context.Context) error {
+		attempts++
+		require.NoError(t, insert("Banana")(ctx))
+		return errFailed
+	}), errFailed)
+	assert.Equal(t, 1, attempts)
+
+	assert.Panics(t, func() {
+		RunInTx(ctx, nil, func(ctx context.Context) error {
+			require.NoError(t, insert("Cherry")(ctx))
+			panic("failed")
+		})
+	})
+
+	require.NoError(t, RunInTx(ctx, nil, func(ctx context.Context) error {
+		require.NoError(t, insert("Durian")(ctx))
+
+		// nested transactions are not retried
+		err := RunInTx(ctx, nil, func(ctx context.Context) error {
+			attempts++
+			require.NoError(t, insert("Elderberry")(ctx))
+			return testSQLStateError("40001")
+		})
+
+		assert.Error(t, err)
+		return nil
+	}))
+	assert.Equal(t, 2, attempts)
+
+	fruits, err := Query[struct {
+		Name string `db:"name"`
+	}](ctx, SQL{Query: `select * from "fruits" order by "name" ;`})
+
+	require.NoError(t, err)
+	assert.Len(t, fruits, 2)
+	assert.Equal(t, "Apple", fruits[0].Name)
+	assert.Equal(t, "Durian", fruits[1].Name)
+}
+
+func TestTransactionHooks(t *testing.T) {
+	db, err := Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := WithDatabase(context.Background(), db)
+
+	var calls []string
+	record := func(call string) func() {
+		return func() { calls = append(calls, call) }
+	}
+
+	AfterCommit(ctx, record("no transaction commit"))
+	AfterRollback(ctx, record("no transaction rollback"))
+	assert.Equal(t, []string{"no transaction commit"}, calls)
+	calls = nil
+
+	txCtx, tx, err := Begin(ctx, nil)
+	require.NoError(t, err)
+
+	AfterCommit(txCtx, record("commit 1"))
+	AfterRollback(txCtx, record("rollback 1"))
+
+	nestedCtx, nestedTx, err := Begin(txCtx, nil)
+	require.NoError(t, err)
+	AfterCommit(nestedCtx, record("released commit"))
+	AfterRollback(nestedCtx, record("released rollback"))
+	require.NoError(t, nestedTx.Commit())
+
+	nestedCtx, nestedTx, err = Begin(txCtx, nil)
+	require.NoError(t, err)
+	AfterCommit(nestedCtx, record("rolled back commit"))
+	AfterRollback(nestedCtx, record("rolled back rollback"))
+	require.NoError(t, nestedTx.Rollback())
+
+	assert.Equal(t, []string{"rolled back rollback"}, calls)
+	calls = nil
+
+	AfterCommit(txCtx, record("commit 2"))
+	assert.Empty(t, calls)
+
+	require.NoError(t, tx.Commit())
+	assert.ErrorIs(t, tx.Rollback(), sql.ErrTxDone)
+	assert.Equal(t, []string{"commit 1", "released commit", "commit 2"}, calls)
+	calls = nil
+
+	txCtx, tx, err = Begin(ctx, nil)
+	require.NoError(t, err)
+
+	AfterCommit(txCtx, record("commit"))
+	AfterRollback(txCtx, record("rollback 1"))
+
+	nestedCtx, nestedTx, err = Begin(txCtx, nil)
+	require.NoError(t, err)
+	AfterRollback(nestedCtx, record("rollback 2"))
+	require.NoError(t, nestedTx.Commit())
+
+	require.NoError(t, tx.Rollback())
+	assert.Equal(t, []string{"rollback 1", "rollback 2"}, calls)
+}